@@ -0,0 +1,241 @@
+package winvps
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Represents a single audited mutating api call
+type AuditEntry struct {
+	Time      time.Time `json:"time"`
+	Actor     string    `json:"actor,omitempty"`
+	Operation string    `json:"operation"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	// Machine name, taken from the response for created machines
+	Machine string `json:"machine,omitempty"`
+	// Request options, password fields are redacted
+	Options json.RawMessage `json:"options,omitempty"`
+	Jobs    []int           `json:"jobs,omitempty"`
+	Status  int             `json:"status,omitempty"`
+	Error   string          `json:"error,omitempty"`
+	// Request wasn't sent, see DryRun()
+	DryRun bool `json:"dry_run,omitempty"`
+}
+
+// Represents a destination of audit entries, safe for concurrent use
+type AuditSink interface {
+	Record(e *AuditEntry) error
+}
+
+// Adapts a function to AuditSink
+type AuditSinkFunc func(e *AuditEntry) error
+
+// Record calls f(e)
+func (f AuditSinkFunc) Record(e *AuditEntry) error {
+	return f(e)
+}
+
+// Represents audit journal settings
+type audit struct {
+	sink  AuditSink
+	actor string
+}
+
+// Record every mutating call to the sink as performed by actor, e.g. a user or service name.
+// Read-only calls aren't recorded. Failure to record doesn't fail the call, it's logged with
+// the Logger() of the client if it's set
+func Audit(sink AuditSink, actor string) Option {
+	return func(c *Client) error {
+		c.audit = &audit{sink: sink, actor: actor}
+		return nil
+	}
+}
+
+// Record mutating round-trips to the sink
+func (a *audit) wrap(next RoundTripFunc, dryRun bool, logger *slog.Logger) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, []byte, error) {
+		if req.Method == http.MethodGet || req.Method == http.MethodHead {
+			return next(req)
+		}
+
+		e := &AuditEntry{
+			Time:      time.Now().UTC(),
+			Actor:     a.actor,
+			Operation: OperationFromContext(req.Context()),
+			Method:    req.Method,
+			Path:      req.URL.Path,
+			Machine:   machineFromPath(req.URL.Path),
+			DryRun:    dryRun,
+		}
+		if req.GetBody != nil {
+			if body, err := req.GetBody(); err == nil {
+				if data, err := io.ReadAll(body); err == nil && len(data) > 0 {
+					e.Options = redactJSON(data)
+				}
+			}
+		}
+
+		resp, body, err := next(req)
+
+		e.Jobs = jobIDs(body)
+		if resp != nil {
+			e.Status = resp.StatusCode
+			if err == nil {
+				checked := *resp
+				checked.Body = io.NopCloser(bytes.NewReader(body))
+				if err := CheckResponse(&checked); err != nil {
+					e.Error = err.Error()
+				}
+			}
+		}
+		if err != nil {
+			e.Error = err.Error()
+		}
+		if e.Machine == "" && e.Error == "" {
+			e.Machine = createdMachine(body)
+		}
+		if rerr := a.sink.Record(e); rerr != nil && logger != nil {
+			logger.LogAttrs(req.Context(), slog.LevelError, "winvps audit record failed",
+				slog.String("operation", e.Operation),
+				slog.String("machine", e.Machine),
+				slog.String("error", rerr.Error()),
+			)
+		}
+		return resp, body, err
+	}
+}
+
+// Returns machine name from api path like /api/v2/machines/NAME/restart, empty if there is none
+func machineFromPath(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, p := range parts {
+		if p == "machines" && i+1 < len(parts) {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
+
+// Returns name of the machine from CreateMachine() response body
+func createdMachine(body []byte) string {
+	result := new(struct {
+		Data struct {
+			Name string `json:"name"`
+		} `json:"data"`
+	})
+	if err := json.Unmarshal(body, result); err != nil {
+		return ""
+	}
+	return result.Data.Name
+}
+
+// Represents a sink appending json lines to a file
+type auditFile struct {
+	mu   sync.Mutex
+	path string
+}
+
+// Returns sink appending entries as json lines to the file, which is created readable by the owner only
+func AuditFile(path string) AuditSink {
+	return &auditFile{path: path}
+}
+
+func (a *auditFile) Record(e *AuditEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Represents audit journal query, zero fields match any entry
+type AuditQuery struct {
+	Machine   string
+	Operation string
+	Actor     string
+	// Entries recorded at or after Since and before Until
+	Since time.Time
+	Until time.Time
+	// Only failed calls
+	Failed bool
+}
+
+// Reports whether the entry matches the query
+func (q *AuditQuery) Match(e *AuditEntry) bool {
+	return (q.Machine == "" || q.Machine == e.Machine) &&
+		(q.Operation == "" || q.Operation == e.Operation) &&
+		(q.Actor == "" || q.Actor == e.Actor) &&
+		(q.Since.IsZero() || !e.Time.Before(q.Since)) &&
+		(q.Until.IsZero() || e.Time.Before(q.Until)) &&
+		(!q.Failed || e.Error != "")
+}
+
+// Read json lines journal and return entries matching the query, nil query matches all entries
+func ReadAudit(r io.Reader, q *AuditQuery) ([]*AuditEntry, error) {
+	if q == nil {
+		q = &AuditQuery{}
+	}
+	var entries []*AuditEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		e := &AuditEntry{}
+		if err := json.Unmarshal(line, e); err != nil {
+			return nil, err
+		}
+		if q.Match(e) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// Read json lines journal file and return entries matching the query
+func ReadAuditFile(path string, q *AuditQuery) ([]*AuditEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadAudit(f, q)
+}
+
+// Returns IDs of jobs from response body of mutating calls, nil if there are no jobs
+func jobIDs(body []byte) []int {
+	result := new(struct {
+		Data struct {
+			Jobs []*Job `json:"jobs"`
+		} `json:"data"`
+	})
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil
+	}
+	var ids []int
+	for _, j := range result.Data.Jobs {
+		ids = append(ids, j.ID)
+	}
+	return ids
+}
@@ -0,0 +1,156 @@
+package winvps
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	require.NoError(t, Audit(AuditFile(path), "alice")(client))
+
+	mux.HandleFunc(apiVerPath+"machines", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			writeFixture(t, w, "machines.json")
+			return
+		}
+		writeFixture(t, w, "machinecreate.json")
+	})
+	mux.HandleFunc(apiVerPath+"machines/VPS01/change_password", func(w http.ResponseWriter, r *http.Request) {
+		writeFixture(t, w, "change_password.json")
+	})
+	mux.HandleFunc(apiVerPath+"machines/VPS01/restart", func(w http.ResponseWriter, r *http.Request) {
+		writeFixture(t, w, "jobspost.json")
+	})
+	mux.HandleFunc(apiVerPath+"machines/VPS02/restart", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"machine not found"}`))
+	})
+
+	_, _, err := client.GetMachines()
+	require.NoError(t, err)
+	_, _, err = client.CreateMachine(&CreateMachineOptions{ProductID: 1, TemplateID: 2, LocationID: 3, Password: "Secret-123"})
+	require.NoError(t, err)
+	_, err = client.ChangeMachinePassword("VPS01", "Secret-123")
+	require.NoError(t, err)
+	_, err = client.SendMachineCommand("VPS01", "restart")
+	require.NoError(t, err)
+	_, err = client.SendMachineCommand("VPS02", "restart")
+	require.Error(t, err)
+
+	entries, err := ReadAuditFile(path, nil)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	for _, e := range entries {
+		require.Equal(t, "alice", e.Actor)
+		require.False(t, e.Time.IsZero())
+		require.False(t, e.DryRun)
+		require.NotContains(t, string(e.Options), "Secret-123")
+	}
+
+	require.Equal(t, "CreateMachine", entries[0].Operation)
+	require.Equal(t, http.MethodPost, entries[0].Method)
+	require.Equal(t, "VPS0123", entries[0].Machine)
+	require.JSONEq(t, `{"password":"[REDACTED]","product_id":1,"template_id":2,"location_id":3}`, string(entries[0].Options))
+	require.Equal(t, []int{1}, entries[0].Jobs)
+
+	require.Equal(t, "ChangeMachinePassword", entries[1].Operation)
+	require.Equal(t, "VPS01", entries[1].Machine)
+	require.JSONEq(t, `{"password":"[REDACTED]"}`, string(entries[1].Options))
+
+	require.Equal(t, "SendMachineCommand", entries[2].Operation)
+	require.Equal(t, "/api/v2/machines/VPS01/restart", entries[2].Path)
+	require.Equal(t, []int{1}, entries[2].Jobs)
+	require.Empty(t, entries[2].Error)
+
+	require.Equal(t, "VPS02", entries[3].Machine)
+	require.Equal(t, http.StatusNotFound, entries[3].Status)
+	require.Contains(t, entries[3].Error, "machine not found")
+
+	entries, err = ReadAuditFile(path, &AuditQuery{Machine: "VPS01"})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	entries, err = ReadAuditFile(path, &AuditQuery{Failed: true})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	entries, err = ReadAuditFile(path, &AuditQuery{Operation: "SendMachineCommand", Actor: "alice"})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	entries, err = ReadAuditFile(path, &AuditQuery{Since: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestAuditDryRun(t *testing.T) {
+	_, server, client := setup(t)
+	defer teardown(server)
+
+	var entries []*AuditEntry
+	require.NoError(t, DryRun(true)(client))
+	require.NoError(t, Audit(AuditSinkFunc(func(e *AuditEntry) error {
+		entries = append(entries, e)
+		return nil
+	}), "")(client))
+
+	_, err := client.DeleteMachine("VPS01")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.True(t, entries[0].DryRun)
+	require.Equal(t, "DeleteMachine", entries[0].Operation)
+	require.Equal(t, "VPS01", entries[0].Machine)
+}
+
+func TestReadAudit(t *testing.T) {
+	journal := `{"time":"2020-10-20T01:02:03Z","actor":"bob","operation":"DeleteMachine","method":"DELETE","path":"/api/v2/machines/VPS01","machine":"VPS01"}
+
+{"time":"2020-10-21T01:02:03Z","actor":"alice","operation":"CancelJob","method":"DELETE","path":"/api/v2/jobs/5","error":"status: 404"}
+`
+	since := time.Date(2020, 10, 21, 0, 0, 0, 0, time.UTC)
+	entries, err := ReadAudit(strings.NewReader(journal), &AuditQuery{Since: since})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "alice", entries[0].Actor)
+
+	entries, err = ReadAudit(strings.NewReader(journal), &AuditQuery{Until: since})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "VPS01", entries[0].Machine)
+
+	_, err = ReadAudit(strings.NewReader("not json\n"), nil)
+	require.Error(t, err)
+}
+
+func TestAuditSinkFailure(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	mux.HandleFunc(apiVerPath+"machines/VPS01/restart", func(w http.ResponseWriter, r *http.Request) {
+		writeFixture(t, w, "jobspost.json")
+	})
+
+	sink := AuditSinkFunc(func(e *AuditEntry) error {
+		return fmt.Errorf("disk full")
+	})
+	require.NoError(t, Audit(sink, "alice")(client))
+
+	// without logger the failure is ignored
+	_, err := client.SendMachineCommand("VPS01", "restart")
+	require.NoError(t, err)
+
+	b := new(bytes.Buffer)
+	require.NoError(t, Logger(slog.New(slog.NewJSONHandler(b, nil)))(client))
+	_, err = client.SendMachineCommand("VPS01", "restart")
+	require.NoError(t, err)
+	require.Contains(t, b.String(), `"msg":"winvps audit record failed","operation":"SendMachineCommand","machine":"VPS01","error":"disk full"`)
+}
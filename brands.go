@@ -15,7 +15,7 @@ type Brand struct {
 func (c *Client) GetBrands(opts ...*RequestOptions) ([]*Brand, *Pagination, error) {
 	u := "brands"
 
-	req, err := c.NewRequest(http.MethodGet, u, nil, opts)
+	req, err := c.newRequest("GetBrands", http.MethodGet, u, nil, opts)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -0,0 +1,158 @@
+package winvps
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Represents bulk operation options
+type BulkOptions struct {
+	// Maximum number of machines processed at once, 10 by default
+	Concurrency int
+	// Wait until resulting jobs are done
+	Wait bool
+	// Jobs poll interval, 5s by default
+	Interval time.Duration
+	// Maximum wait for jobs of a single machine, 0 means wait forever
+	Timeout time.Duration
+}
+
+// Represents result of bulk operation on a single machine
+type BulkResult struct {
+	Machine string `json:"machine"`
+	Success bool   `json:"success"`
+	// Protected machine wasn't processed, see Guard(). Skipped machine isn't failed
+	Skipped bool   `json:"skipped,omitempty"`
+	Jobs    []*Job `json:"jobs"`
+	Error   string `json:"error,omitempty"`
+	Err     error  `json:"-"`
+}
+
+// Represents results of bulk operation in order of passed machines
+type BulkResults []*BulkResult
+
+// Returns results of failed machines
+func (r BulkResults) Failed() BulkResults {
+	var failed BulkResults
+	for _, result := range r {
+		if !result.Success && !result.Skipped {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Returns results of skipped protected machines
+func (r BulkResults) Skipped() BulkResults {
+	var skipped BulkResults
+	for _, result := range r {
+		if result.Skipped {
+			skipped = append(skipped, result)
+		}
+	}
+	return skipped
+}
+
+// Returns error describing failed machines, nil if all machines succeeded
+func (r BulkResults) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d machines failed, first error: %s: %v", len(failed), len(r), failed[0].Machine, failed[0].Err)
+}
+
+// Represents a function filtering machines
+type MachineFilter func(m *MachineFull) bool
+
+// Returns names of all machines accepted by filter, nil filter accepts all machines
+func (c *Client) MachineNames(filter MachineFilter) ([]string, error) {
+	machines, err := ListAll(c.GetMachinesFull)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, m := range machines {
+		if m.Machine != nil && (filter == nil || filter(m)) {
+			names = append(names, m.Name)
+		}
+	}
+	return names, nil
+}
+
+// Run fn for each machine concurrently. Failure of a machine doesn't stop the others,
+// errors are reported in per-machine results. Protected machines, see Guard(), are skipped
+// without counting as failed, see BulkResult.Skipped
+func (c *Client) Bulk(names []string, opt *BulkOptions, fn func(name string) ([]*Job, error)) BulkResults {
+	protected, err := c.ProtectedMachines()
+	if err != nil {
+		err = fmt.Errorf("unable to check protection: %v", err)
+	}
+	return c.bulk(names, opt, protected, err, fn)
+}
+
+// Run fn for each machine not in protected set concurrently, all machines fail with
+// protectedErr if it's set
+func (c *Client) bulk(names []string, opt *BulkOptions, protected map[string]bool, protectedErr error, fn func(name string) ([]*Job, error)) BulkResults {
+	if opt == nil {
+		opt = &BulkOptions{}
+	}
+	concurrency := opt.Concurrency
+	if concurrency <= 0 {
+		concurrency = 10
+	}
+	interval := opt.Interval
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+
+	results := make(BulkResults, len(names))
+	sem := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	for i, name := range names {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, name string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			r := &BulkResult{Machine: name}
+			switch {
+			case protectedErr != nil:
+				r.Err = protectedErr
+			case protected[name]:
+				r.Skipped = true
+				results[i] = r
+				return
+			default:
+				r.Jobs, r.Err = fn(name)
+			}
+			if r.Err == nil && opt.Wait {
+				r.Err = c.WaitJobs(r.Jobs, interval, opt.Timeout)
+			}
+			r.Success = r.Err == nil
+			if r.Err != nil {
+				r.Error = r.Err.Error()
+			}
+			results[i] = r
+		}(i, name)
+	}
+	wg.Wait()
+	return results
+}
+
+// Send command to each machine concurrently, see SendMachineCommand() for available commands
+func (c *Client) BulkCommand(names []string, command string, opt *BulkOptions) BulkResults {
+	return c.Bulk(names, opt, func(name string) ([]*Job, error) {
+		return c.SendMachineCommand(name, command)
+	})
+}
+
+// Update each machine concurrently with the same UpdateMachineOptions
+func (c *Client) BulkUpdate(names []string, upd *UpdateMachineOptions, opt *BulkOptions) BulkResults {
+	return c.Bulk(names, opt, func(name string) ([]*Job, error) {
+		return c.UpdateMachine(name, upd)
+	})
+}
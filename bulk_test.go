@@ -0,0 +1,100 @@
+package winvps
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkCommand(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	mu := sync.Mutex{}
+	running, maxRunning := 0, 0
+	for i := 1; i <= 5; i++ {
+		i := i
+		mux.HandleFunc(fmt.Sprintf("%smachines/VPS0%d/restart", apiVerPath, i), func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+
+			if i == 3 {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"error":"machine not found"}`)
+				return
+			}
+			fmt.Fprintf(w, `{"data":{"jobs":[{"id":%d,"status":"Pending"}]}}`, i)
+		})
+		mux.HandleFunc(fmt.Sprintf("%sjobs/%d", apiVerPath, i), func(w http.ResponseWriter, r *http.Request) {
+			status := JobStatusComplete
+			if i == 5 {
+				status = JobStatusFailed
+			}
+			fmt.Fprintf(w, `{"data":{"id":%d,"status":"%s"}}`, i, status)
+		})
+	}
+
+	names := []string{"VPS01", "VPS02", "VPS03", "VPS04", "VPS05"}
+	results := client.BulkCommand(names, "restart", &BulkOptions{Concurrency: 2, Wait: true, Interval: time.Millisecond})
+	require.Len(t, results, 5)
+	require.LessOrEqual(t, maxRunning, 2)
+	for i, r := range results {
+		require.Equal(t, names[i], r.Machine)
+	}
+	require.True(t, results[0].Success)
+	require.Equal(t, []*Job{{ID: 1, Status: JobStatusComplete}}, results[0].Jobs)
+	require.Equal(t, "status: 404, error: machine not found", results[2].Error)
+	require.Equal(t, "job 5 failed", results[4].Error)
+
+	failed := results.Failed()
+	require.Len(t, failed, 2)
+	require.EqualError(t, results.Err(), "2 of 5 machines failed, first error: VPS03: status: 404, error: machine not found")
+
+	results = client.BulkCommand([]string{"VPS01"}, "explode", nil)
+	require.False(t, results[0].Success)
+	require.Contains(t, results[0].Error, "wrong command passed 'explode'")
+}
+
+func TestBulkUpdate(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	mux.HandleFunc(apiVerPath+"machines/VPS01", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPut, r.Method)
+		require.JSONEq(t, `{"add_ram":1024}`, getBody(t, r))
+		writeFixture(t, w, "jobspost.json")
+	})
+
+	results := client.BulkUpdate([]string{"VPS01"}, &UpdateMachineOptions{AddRam: 1024}, nil)
+	require.NoError(t, results.Err())
+	require.Len(t, results[0].Jobs, 1)
+}
+
+func TestMachineNames(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	mux.HandleFunc(apiVerPath+"machines/full", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"name":"VPS01","status":"Running"},{"name":"VPS02","status":"Stopped"}],"pagination":{"total":2,"limit":50,"page":1,"pages":1}}`)
+	})
+
+	got, err := client.MachineNames(func(m *MachineFull) bool { return m.Status == MachineStatusStopped })
+	require.NoError(t, err)
+	require.Equal(t, []string{"VPS02"}, got)
+
+	got, err = client.MachineNames(nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"VPS01", "VPS02"}, got)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/fozzyhosting/winvps-go-client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Name of the product label for machines whose config doesn't match any product limits
+const customProduct = "custom"
+
+var (
+	machinesDesc = prometheus.NewDesc("winvps_machines",
+		"Number of machines per status.", []string{"status"}, nil)
+	productMachinesDesc = prometheus.NewDesc("winvps_product_machines",
+		"Number of machines per product, machines with additional resources are counted as custom.", []string{"product"}, nil)
+	cpuCoresDesc = prometheus.NewDesc("winvps_machine_cpu_cores",
+		"Configured CPU cores.", []string{"machine"}, nil)
+	cpuPercentDesc = prometheus.NewDesc("winvps_machine_cpu_percent",
+		"Configured CPU limit in percent.", []string{"machine"}, nil)
+	ramMinDesc = prometheus.NewDesc("winvps_machine_ram_min_megabytes",
+		"Configured minimal RAM.", []string{"machine"}, nil)
+	ramMaxDesc = prometheus.NewDesc("winvps_machine_ram_max_megabytes",
+		"Configured maximal RAM.", []string{"machine"}, nil)
+	diskDesc = prometheus.NewDesc("winvps_machine_disk_size_gigabytes",
+		"Configured disk size.", []string{"machine"}, nil)
+	bandwidthDesc = prometheus.NewDesc("winvps_machine_bandwidth",
+		"Configured bandwidth.", []string{"machine"}, nil)
+	rebootDesc = prometheus.NewDesc("winvps_machine_reboot_required",
+		"Whether the machine requires reboot to finish updates installation.", []string{"machine"}, nil)
+	pendingJobsDesc = prometheus.NewDesc("winvps_pending_jobs",
+		"Number of pending jobs per type.", []string{"type"}, nil)
+	refreshSuccessDesc = prometheus.NewDesc("winvps_refresh_success",
+		"Whether the last refresh of fleet state succeeded.", nil, nil)
+	refreshTimeDesc = prometheus.NewDesc("winvps_refresh_timestamp_seconds",
+		"Unix time of the last successful refresh.", nil, nil)
+)
+
+// Represents fleet state fetched from api
+type snapshot struct {
+	machines    []*winvps.MachineFull
+	products    []*winvps.Product
+	pendingJobs []*winvps.Job
+	time        time.Time
+}
+
+// Collects fleet metrics from cached snapshot, so scrapes never hit the api
+type collector struct {
+	client *winvps.Client
+
+	mu      sync.RWMutex
+	state   *snapshot
+	success bool
+}
+
+func newCollector(client *winvps.Client) *collector {
+	return &collector{client: client}
+}
+
+// Fetch fleet state and replace the cached snapshot, previous snapshot is kept on error
+func (c *collector) refresh() error {
+	s, err := c.fetch()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.success = err == nil
+	if err != nil {
+		return err
+	}
+	c.state = s
+	return nil
+}
+
+func (c *collector) fetch() (*snapshot, error) {
+	machines, err := winvps.ListAll(c.client.GetMachinesFull)
+	if err != nil {
+		return nil, err
+	}
+	products, err := winvps.ListAll(c.client.GetProducts)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := winvps.ListAll(c.client.GetPendingJobs)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot{machines: machines, products: products, pendingJobs: jobs, time: time.Now()}, nil
+}
+
+// Refresh fleet state every interval until stop is closed
+func (c *collector) run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.refresh(); err != nil {
+				log.Printf("failed to refresh fleet state: %v", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{machinesDesc, productMachinesDesc, cpuCoresDesc, cpuPercentDesc,
+		ramMinDesc, ramMaxDesc, diskDesc, bandwidthDesc, rebootDesc, pendingJobsDesc, refreshSuccessDesc, refreshTimeDesc} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	s, success := c.state, c.success
+	c.mu.RUnlock()
+
+	ch <- prometheus.MustNewConstMetric(refreshSuccessDesc, prometheus.GaugeValue, boolValue(success))
+	if s == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(refreshTimeDesc, prometheus.GaugeValue, float64(s.time.Unix()))
+
+	statuses := map[string]int{}
+	products := map[string]int{}
+	for _, m := range s.machines {
+		if m.Machine == nil {
+			continue
+		}
+		statuses[m.Status]++
+		products[productName(m.Config, s.products)]++
+		if l := m.Config; l != nil {
+			gauge(ch, cpuCoresDesc, l.CpuCores, m.Name)
+			gauge(ch, cpuPercentDesc, l.CpuPercent, m.Name)
+			gauge(ch, ramMinDesc, l.RamMin, m.Name)
+			gauge(ch, ramMaxDesc, l.RamMax, m.Name)
+			gauge(ch, diskDesc, l.DiskSize, m.Name)
+			gauge(ch, bandwidthDesc, l.Bandwidth, m.Name)
+		}
+		if m.OS != nil && m.OS.UpdateStatus != nil {
+			ch <- prometheus.MustNewConstMetric(rebootDesc, prometheus.GaugeValue,
+				boolValue(m.OS.UpdateStatus.RebootRequired), m.Name)
+		}
+	}
+	for status, n := range statuses {
+		gauge(ch, machinesDesc, n, status)
+	}
+	for product, n := range products {
+		gauge(ch, productMachinesDesc, n, product)
+	}
+
+	types := map[string]int{}
+	for _, j := range s.pendingJobs {
+		types[j.Type]++
+	}
+	for t, n := range types {
+		gauge(ch, pendingJobsDesc, n, t)
+	}
+}
+
+// Returns name of the product matching machine config, custom if there is no such product
+func productName(config *winvps.Limits, products []*winvps.Product) string {
+	if p := winvps.MatchProduct(config, products); p != nil {
+		return p.Name
+	}
+	return customProduct
+}
+
+func gauge(ch chan<- prometheus.Metric, desc *prometheus.Desc, v int, labels ...string) {
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(v), labels...)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fozzyhosting/winvps-go-client"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	machinesFull = `{"data":[
+		{"name":"VPS01","status":"Running","config":{"cpu_cores":1,"cpu_percent":100,"ram_min":1024,"ram_max":1024,"disk_size":30,"bandwidth":10},
+		 "os":{"template_id":"1","brand_id":1,"update_status":{"reboot_required":true}}},
+		{"name":"VPS02","status":"Stopped","config":{"cpu_cores":2,"cpu_percent":100,"ram_min":2048,"ram_max":2048,"disk_size":60,"bandwidth":10}}
+	],"pagination":{"total":2,"limit":50,"page":1,"pages":1}}`
+	products    = `{"data":[{"id":1,"name":"start","limits":{"cpu_cores":1,"cpu_percent":100,"ram_min":1024,"ram_max":1024,"disk_size":30,"bandwidth":10}}],"pagination":{"total":1,"limit":50,"page":1,"pages":1}}`
+	pendingJobs = `{"data":[{"id":1,"type":"Change"},{"id":2,"type":"Change"},{"id":3,"type":"Initialize"}],"pagination":{"total":3,"limit":50,"page":1,"pages":1}}`
+)
+
+func TestCollector(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fail := false
+	serve := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if fail {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(`{"error":"internal"}`))
+				return
+			}
+			w.Write([]byte(body))
+		}
+	}
+	mux.HandleFunc("/api/v2/machines/full", serve(machinesFull))
+	mux.HandleFunc("/api/v2/products", serve(products))
+	mux.HandleFunc("/api/v2/jobs/pending", serve(pendingJobs))
+
+	client, err := winvps.NewClient("secret", winvps.BaseURL(server.URL))
+	require.NoError(t, err)
+
+	c := newCollector(client)
+	require.NoError(t, c.refresh())
+
+	want := `
+# HELP winvps_machines Number of machines per status.
+# TYPE winvps_machines gauge
+winvps_machines{status="Running"} 1
+winvps_machines{status="Stopped"} 1
+# HELP winvps_product_machines Number of machines per product, machines with additional resources are counted as custom.
+# TYPE winvps_product_machines gauge
+winvps_product_machines{product="custom"} 1
+winvps_product_machines{product="start"} 1
+# HELP winvps_machine_cpu_cores Configured CPU cores.
+# TYPE winvps_machine_cpu_cores gauge
+winvps_machine_cpu_cores{machine="VPS01"} 1
+winvps_machine_cpu_cores{machine="VPS02"} 2
+# HELP winvps_machine_reboot_required Whether the machine requires reboot to finish updates installation.
+# TYPE winvps_machine_reboot_required gauge
+winvps_machine_reboot_required{machine="VPS01"} 1
+# HELP winvps_pending_jobs Number of pending jobs per type.
+# TYPE winvps_pending_jobs gauge
+winvps_pending_jobs{type="Change"} 2
+winvps_pending_jobs{type="Initialize"} 1
+# HELP winvps_refresh_success Whether the last refresh of fleet state succeeded.
+# TYPE winvps_refresh_success gauge
+winvps_refresh_success 1
+`
+	names := []string{"winvps_machines", "winvps_product_machines", "winvps_machine_cpu_cores",
+		"winvps_machine_reboot_required", "winvps_pending_jobs", "winvps_refresh_success"}
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(want), names...))
+
+	// failed refresh keeps serving the previous snapshot
+	fail = true
+	require.Error(t, c.refresh())
+	require.Equal(t, 2, testutil.CollectAndCount(c, "winvps_machines"))
+	wantFailed := `
+# HELP winvps_refresh_success Whether the last refresh of fleet state succeeded.
+# TYPE winvps_refresh_success gauge
+winvps_refresh_success 0
+`
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(wantFailed), "winvps_refresh_success"))
+}
@@ -0,0 +1,57 @@
+// Command winvps-exporter serves Prometheus metrics about winvps fleet state.
+//
+// Machines, products and pending jobs are fetched from the api every refresh interval
+// and cached, scrapes of /metrics are served from the cache. The api doesn't return
+// a location of a machine, so there are no per location metrics.
+//
+// Usage:
+//
+//	WINVPS_TOKEN=token winvps-exporter -listen :9876 -interval 1m
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/fozzyhosting/winvps-go-client"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func main() {
+	listen := flag.String("listen", ":9876", "address to serve metrics on")
+	interval := flag.Duration("interval", time.Minute, "fleet state refresh interval")
+	token := flag.String("token", os.Getenv("WINVPS_TOKEN"), "api token, WINVPS_TOKEN env is used by default")
+	baseURL := flag.String("base-url", "https://winvps.fozzy.com", "api base url")
+	flag.Parse()
+
+	if *interval <= 0 {
+		fmt.Fprintf(flag.CommandLine.Output(), "invalid -interval %s, it must be positive\n", *interval)
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *token == "" {
+		log.Fatal("api token is required")
+	}
+	client, err := winvps.NewClient(*token, winvps.BaseURL(*baseURL))
+	if err != nil {
+		log.Fatalf("Failed to create client: %v", err)
+	}
+
+	c := newCollector(client)
+	if err := c.refresh(); err != nil {
+		log.Printf("failed to refresh fleet state: %v", err)
+	}
+	go c.run(*interval, nil)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	http.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+
+	log.Printf("serving metrics on %s", *listen)
+	log.Fatal(http.ListenAndServe(*listen, nil))
+}
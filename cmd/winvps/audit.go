@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fozzyhosting/winvps-go-client"
+)
+
+func init() {
+	registerOffline("audit", "query audit journal written with -audit flag: [-machine] [-operation] [-actor] [-since] [-until] [-failed] <file>", auditQuery)
+}
+
+func auditQuery(a *app, args []string) error {
+	fs := newFlagSet(a, "audit")
+	q := &winvps.AuditQuery{}
+	fs.StringVar(&q.Machine, "machine", "", "only calls on the machine")
+	fs.StringVar(&q.Operation, "operation", "", "only the operation, e.g. DeleteMachine")
+	fs.StringVar(&q.Actor, "actor", "", "only calls made by the actor")
+	since := fs.Duration("since", 0, "only calls made within the duration, e.g. 24h")
+	until := fs.String("until", "", "only calls made before the RFC 3339 time")
+	fs.BoolVar(&q.Failed, "failed", false, "only failed calls")
+	rest, err := parseArgs(fs, args, "file")
+	if err != nil {
+		return err
+	}
+	if *since > 0 {
+		q.Since = time.Now().Add(-*since)
+	}
+	if *until != "" {
+		if q.Until, err = time.Parse(time.RFC3339, *until); err != nil {
+			return fmt.Errorf("invalid -until: %v", err)
+		}
+	}
+	entries, err := winvps.ReadAuditFile(rest[0], q)
+	if err != nil {
+		return err
+	}
+	return a.print(entries)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/fozzyhosting/winvps-go-client"
+)
+
+func init() {
+	register("machines bulk-command", "send command to many machines concurrently: [-names|-all|-status|-select] COMMAND", machinesBulkCommand)
+	register("machines bulk-update", "update many machines concurrently: [-names|-all|-status|-select] [flags]", machinesBulkUpdate)
+}
+
+// Represents machine selection flags
+type selectFlags struct {
+	names    listFlag
+	all      bool
+	status   string
+	selector string
+}
+
+func addSelectFlags(fs *flag.FlagSet) *selectFlags {
+	s := &selectFlags{}
+	fs.Var(&s.names, "names", "comma separated machine names")
+	fs.BoolVar(&s.all, "all", false, "select all machines")
+	fs.StringVar(&s.status, "status", "", "select machines with status, e.g. Running")
+	fs.StringVar(&s.selector, "select", "", "select machines matching selector, e.g. name=VPS0*,ip=10.0.0.0/8")
+	return s
+}
+
+// Returns selector of -status and -select flags, nil if machines are selected by name or -all
+func (s *selectFlags) parse() (*winvps.Selector, error) {
+	switch {
+	case len(s.names) > 0:
+		if s.all || s.status != "" || s.selector != "" {
+			return nil, fmt.Errorf("-names can't be used with -all, -status or -select")
+		}
+		return nil, nil
+	case s.status != "" || s.selector != "":
+		sel, err := winvps.ParseSelector(s.selector)
+		if err != nil {
+			return nil, err
+		}
+		if s.status != "" {
+			sel.Status = s.status
+		}
+		return sel, nil
+	case s.all:
+		return &winvps.Selector{}, nil
+	}
+	return nil, fmt.Errorf("no machines selected, use -names, -all, -status or -select")
+}
+
+// Returns names of selected machines
+func (s *selectFlags) machines(a *app) ([]string, error) {
+	sel, err := s.parse()
+	if err != nil || sel == nil {
+		return s.names, err
+	}
+	return a.client.SelectNames(sel)
+}
+
+// Returns full info of selected machines
+func (s *selectFlags) machinesFull(a *app) ([]*winvps.MachineFull, error) {
+	sel, err := s.parse()
+	if err != nil {
+		return nil, err
+	}
+	if sel != nil {
+		return a.client.SelectMachines(sel)
+	}
+	var machines []*winvps.MachineFull
+	for _, name := range s.names {
+		m, err := a.client.GetMachine(name)
+		if err != nil {
+			return nil, err
+		}
+		machines = append(machines, m)
+	}
+	return machines, nil
+}
+
+// Represents machine selection and concurrency flags of bulk commands
+type bulkFlags struct {
+	*selectFlags
+	opt winvps.BulkOptions
+}
+
+func addBulkFlags(fs *flag.FlagSet) *bulkFlags {
+	b := &bulkFlags{selectFlags: addSelectFlags(fs)}
+	fs.IntVar(&b.opt.Concurrency, "concurrency", 10, "maximum number of machines processed at once")
+	fs.BoolVar(&b.opt.Wait, "wait", false, "wait until resulting jobs are done")
+	fs.DurationVar(&b.opt.Interval, "interval", 5*time.Second, "jobs poll interval")
+	fs.DurationVar(&b.opt.Timeout, "timeout", 0, "maximum wait for jobs of a single machine, 0 means wait forever")
+	return b
+}
+
+// Print results and fail if any machine failed
+func (a *app) printBulk(results winvps.BulkResults) error {
+	if err := a.print(results); err != nil {
+		return err
+	}
+	return results.Err()
+}
+
+func machinesBulkCommand(a *app, args []string) error {
+	fs := newFlagSet(a, "machines bulk-command")
+	b := addBulkFlags(fs)
+	args, err := parseArgs(fs, args, "COMMAND")
+	if err != nil {
+		return err
+	}
+	names, err := b.machines(a)
+	if err != nil {
+		return err
+	}
+	return a.printBulk(a.client.BulkCommand(names, args[0], &b.opt))
+}
+
+func machinesBulkUpdate(a *app, args []string) error {
+	fs := newFlagSet(a, "machines bulk-update")
+	b := addBulkFlags(fs)
+	opt := &winvps.UpdateMachineOptions{}
+	fs.IntVar(&opt.ProductID, "product", 0, "product ID")
+	fs.IntVar(&opt.AddDisk, "add-disk", 0, "additional disk size")
+	fs.IntVar(&opt.AddRam, "add-ram", 0, "additional RAM")
+	fs.IntVar(&opt.AddCpu, "add-cpu", 0, "additional CPU cores")
+	fs.IntVar(&opt.AddBand, "add-band", 0, "additional bandwidth")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	names, err := b.machines(a)
+	if err != nil {
+		return err
+	}
+	return a.printBulk(a.client.BulkUpdate(names, opt, &b.opt))
+}
@@ -0,0 +1,60 @@
+package main
+
+func init() {
+	register("products", "list available products", products)
+	register("templates", "list available templates", templates)
+	register("brands", "list available brands", brands)
+	register("locations", "list available locations", locations)
+}
+
+func products(a *app, args []string) error {
+	fs := newFlagSet(a, "products")
+	page := addPageFlags(fs)
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	items, err := list(page, a.client.GetProducts)
+	if err != nil {
+		return err
+	}
+	return a.print(items)
+}
+
+func templates(a *app, args []string) error {
+	fs := newFlagSet(a, "templates")
+	page := addPageFlags(fs)
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	items, err := list(page, a.client.GetTemplates)
+	if err != nil {
+		return err
+	}
+	return a.print(items)
+}
+
+func brands(a *app, args []string) error {
+	fs := newFlagSet(a, "brands")
+	page := addPageFlags(fs)
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	items, err := list(page, a.client.GetBrands)
+	if err != nil {
+		return err
+	}
+	return a.print(items)
+}
+
+func locations(a *app, args []string) error {
+	fs := newFlagSet(a, "locations")
+	page := addPageFlags(fs)
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	items, err := list(page, a.client.GetLocations)
+	if err != nil {
+		return err
+	}
+	return a.print(items)
+}
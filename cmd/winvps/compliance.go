@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fozzyhosting/winvps-go-client"
+)
+
+func init() {
+	register("machines compliance", "report windows update compliance, fails on non-compliant machines: [-max-age] [-summary]", machinesCompliance)
+}
+
+func machinesCompliance(a *app, args []string) error {
+	fs := newFlagSet(a, "machines compliance")
+	maxAge := fs.Duration("max-age", 30*24*time.Hour, "maximum age of the last update")
+	summary := fs.Bool("summary", false, "print summary per template instead of machines")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	report, err := a.client.Compliance(&winvps.ComplianceOptions{MaxAge: *maxAge})
+	if err != nil {
+		return err
+	}
+	if *summary {
+		err = a.print(report.Templates)
+	} else {
+		err = a.print(report.Machines)
+	}
+	if err != nil {
+		return err
+	}
+	if failed := report.NonCompliant(); len(failed) > 0 {
+		return fmt.Errorf("%d of %d machines not compliant", len(failed), len(report.Machines))
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/fozzyhosting/winvps-go-client"
+)
+
+// Load profile from profiles file and apply flag overrides. Precedence is: flags, env variables,
+// profiles file. Missing default profiles file is not an error
+func loadProfile(path, name, token, baseURL string) (*winvps.Profile, error) {
+	explicit := path != ""
+	if !explicit {
+		path = winvps.DefaultProfilesPath()
+	}
+	ps, err := winvps.LoadProfiles(path)
+	if err != nil && (explicit || !errors.Is(err, os.ErrNotExist)) {
+		return nil, err
+	}
+
+	p, err := ps.Profile(name)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		p.Token = token
+	}
+	if baseURL != "" {
+		p.BaseURL = baseURL
+	}
+	if _, err := p.TokenSource(); err != nil {
+		return nil, fmt.Errorf("api token is required, use -token flag, WINVPS_TOKEN env or profiles file")
+	}
+	return p, nil
+}
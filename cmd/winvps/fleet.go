@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fozzyhosting/winvps-go-client/fleet"
+)
+
+func init() {
+	register("fleet plan", "show changes required to match fleet spec: [-json] SPEC", fleetPlan)
+	register("fleet apply", "apply fleet spec, waiting for jobs: [-yes] SPEC", fleetApply)
+	register("fleet drift", "report machines drifted from desired state, fails on drift: [-json] DESIRED", fleetDrift)
+}
+
+func fleetPlan(a *app, args []string) error {
+	fs := newFlagSet(a, "fleet plan")
+	asJSON := fs.Bool("json", false, "print plan as json")
+	args, err := parseArgs(fs, args, "SPEC")
+	if err != nil {
+		return err
+	}
+	plan, err := loadPlan(a, args[0])
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(a.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(plan)
+	}
+	_, err = fmt.Fprint(a.out, plan)
+	return err
+}
+
+func fleetApply(a *app, args []string) error {
+	fs := newFlagSet(a, "fleet apply")
+	yes := fs.Bool("yes", false, "apply without confirmation")
+	interval := fs.Duration("interval", 5*time.Second, "jobs poll interval")
+	timeout := fs.Duration("timeout", 0, "maximum wait for jobs of a single change, 0 means wait forever")
+	args, err := parseArgs(fs, args, "SPEC")
+	if err != nil {
+		return err
+	}
+	plan, err := loadPlan(a, args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(a.out, plan)
+	if plan.Empty() {
+		return nil
+	}
+	if !*yes {
+		fmt.Fprint(a.out, "\nApply these changes? [y/N] ")
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+			return fmt.Errorf("apply cancelled")
+		}
+	}
+
+	_, err = fleet.Apply(a.client, plan, &fleet.ApplyOptions{
+		Interval: *interval,
+		Timeout:  *timeout,
+		Progress: func(r *fleet.Result) {
+			status := "done"
+			if r.Error != "" {
+				status = "failed: " + r.Error
+			}
+			fmt.Fprintf(a.out, "%s %s (%s) %s\n", r.Change.Action, r.Machine, r.Change.Notes, status)
+		},
+	})
+	return err
+}
+
+func loadPlan(a *app, path string) (*fleet.Plan, error) {
+	spec, err := fleet.LoadSpec(path)
+	if err != nil {
+		return nil, err
+	}
+	return fleet.NewPlan(a.client, spec)
+}
+
+func fleetDrift(a *app, args []string) error {
+	fs := newFlagSet(a, "fleet drift")
+	asJSON := fs.Bool("json", false, "print report as json")
+	args, err := parseArgs(fs, args, "DESIRED")
+	if err != nil {
+		return err
+	}
+	desired, err := fleet.LoadDesired(args[0])
+	if err != nil {
+		return err
+	}
+	report, err := fleet.CheckDrift(a.client, desired)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(a.out)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	} else {
+		_, err = fmt.Fprint(a.out, report)
+	}
+	if err != nil {
+		return err
+	}
+	if report.HasDrift() {
+		return fmt.Errorf("drift detected in %d machines", len(report.Machines))
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fozzyhosting/winvps-go-client"
+)
+
+// Represents global guard rails flags
+type guardFlags struct {
+	deny     listFlag
+	allow    listFlag
+	protect  string
+	maxCalls int
+	confirm  bool
+}
+
+func addGuardFlags(fs *flag.FlagSet) *guardFlags {
+	g := &guardFlags{}
+	fs.Var(&g.deny, "deny", "comma separated machine name patterns delete and reinstall are denied on")
+	fs.Var(&g.allow, "allow", "comma separated machine name patterns delete and reinstall are allowed on")
+	fs.StringVar(&g.protect, "protect", "", "notes tag of protected machines, skipped by delete, reinstall and bulk commands")
+	fs.IntVar(&g.maxCalls, "max-destructive", 0, "maximum number of delete and reinstall calls, 0 means unlimited")
+	fs.BoolVar(&g.confirm, "confirm", false, "ask for confirmation before each delete and reinstall")
+	return g
+}
+
+// Returns guard rails options, nil if no flag is set
+func (g *guardFlags) options(out io.Writer) *winvps.GuardOptions {
+	if len(g.deny) == 0 && len(g.allow) == 0 && g.protect == "" && g.maxCalls == 0 && !g.confirm {
+		return nil
+	}
+	opt := &winvps.GuardOptions{
+		Deny:         g.deny,
+		Allow:        g.allow,
+		ProtectedTag: g.protect,
+		MaxCalls:     g.maxCalls,
+	}
+	if g.confirm {
+		in := bufio.NewReader(os.Stdin)
+		opt.Confirm = func(op, machine string) (bool, error) {
+			fmt.Fprintf(out, "%s %s? [y/N] ", op, machine)
+			answer, _ := in.ReadString('\n')
+			return strings.ToLower(strings.TrimSpace(answer)) == "y", nil
+		}
+	}
+	return opt
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fozzyhosting/winvps-go-client/inventory"
+)
+
+func init() {
+	register("inventory", "print Ansible inventory: [-format yaml|ini] [-vars k=v,...] | --list | --host NAME", inventoryCommand)
+}
+
+func inventoryCommand(a *app, args []string) error {
+	fs := newFlagSet(a, "inventory")
+	format := fs.String("format", "yaml", "static inventory format, yaml or ini")
+	list := fs.Bool("list", false, "print dynamic inventory json")
+	host := fs.String("host", "", "print dynamic inventory variables of the host")
+	var vars listFlag
+	fs.Var(&vars, "vars", "comma separated variables of all hosts, e.g. ansible_connection=winrm")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	inv, err := inventory.Fetch(a.client)
+	if err != nil {
+		return err
+	}
+	for _, v := range vars {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok {
+			return fmt.Errorf("invalid variable '%s', expected key=value", v)
+		}
+		inv.Vars[key] = value
+	}
+
+	switch {
+	case *list:
+		return inv.WriteList(a.out)
+	case *host != "":
+		return inv.WriteHost(a.out, *host)
+	case *format == "yaml":
+		return inv.WriteYAML(a.out)
+	case *format == "ini":
+		return inv.WriteINI(a.out)
+	}
+	return fmt.Errorf("allowed format 'yaml' or 'ini' but '%s' passed", *format)
+}
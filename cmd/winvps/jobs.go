@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/fozzyhosting/winvps-go-client"
+)
+
+func init() {
+	register("jobs list", "list all jobs", jobsList)
+	register("jobs pending", "list pending jobs", jobsPending)
+	register("jobs get", "show job info: ID", jobsGet)
+	register("jobs cancel", "cancel job: ID", jobsCancel)
+	register("jobs wait", "wait until job is done: [-interval] [-timeout] ID", jobsWait)
+	register("jobs tree", "show jobs as parent/child tree with group statuses: [-machine NAME] [-id ID]", jobsTree)
+}
+
+// Parse job ID from args
+func jobID(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid job ID %q", s)
+	}
+	return id, nil
+}
+
+func jobsList(a *app, args []string) error {
+	fs := newFlagSet(a, "jobs list")
+	page := addPageFlags(fs)
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	jobs, err := list(page, a.client.GetJobs)
+	if err != nil {
+		return err
+	}
+	return a.print(jobs)
+}
+
+func jobsPending(a *app, args []string) error {
+	fs := newFlagSet(a, "jobs pending")
+	page := addPageFlags(fs)
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	jobs, err := list(page, a.client.GetPendingJobs)
+	if err != nil {
+		return err
+	}
+	return a.print(jobs)
+}
+
+func jobsGet(a *app, args []string) error {
+	args, err := parseArgs(newFlagSet(a, "jobs get"), args, "ID")
+	if err != nil {
+		return err
+	}
+	id, err := jobID(args[0])
+	if err != nil {
+		return err
+	}
+	job, err := a.client.GetJob(id)
+	if err != nil {
+		return err
+	}
+	return a.print(job)
+}
+
+func jobsCancel(a *app, args []string) error {
+	args, err := parseArgs(newFlagSet(a, "jobs cancel"), args, "ID")
+	if err != nil {
+		return err
+	}
+	id, err := jobID(args[0])
+	if err != nil {
+		return err
+	}
+	return a.client.CancelJob(id)
+}
+
+func jobsWait(a *app, args []string) error {
+	fs := newFlagSet(a, "jobs wait")
+	interval := fs.Duration("interval", 5*time.Second, "poll interval")
+	timeout := fs.Duration("timeout", 0, "maximum wait time, 0 means wait forever")
+	args, err := parseArgs(fs, args, "ID")
+	if err != nil {
+		return err
+	}
+	if *interval <= 0 {
+		return fmt.Errorf("-interval must be positive")
+	}
+	id, err := jobID(args[0])
+	if err != nil {
+		return err
+	}
+	job, err := a.client.WaitJob(id, *interval, *timeout)
+	if err != nil {
+		return err
+	}
+	return a.print(job)
+}
+
+func jobsTree(a *app, args []string) error {
+	fs := newFlagSet(a, "jobs tree")
+	machine := fs.String("machine", "", "only jobs of the machine")
+	id := fs.Int("id", 0, "only the job and its descendants")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	tree, err := a.client.GetJobTree(*machine)
+	if err != nil {
+		return err
+	}
+	if *id != 0 {
+		n := tree.Find(*id)
+		if n == nil {
+			return fmt.Errorf("job %d not found", *id)
+		}
+		tree = winvps.JobTree{n}
+	}
+	_, err = fmt.Fprint(a.out, tree)
+	return err
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fozzyhosting/winvps-go-client"
+)
+
+func init() {
+	register("machines list", "list machines [-full] [-status running|stopped] [-select SELECTOR]", machinesList)
+	register("machines get", "show machine full info: NAME", machinesGet)
+	register("machines create", "create a new machine", machinesCreate)
+	register("machines update", "update machine: [flags] NAME", machinesUpdate)
+	register("machines reinstall", "reinstall machine: [flags] NAME", machinesReinstall)
+	register("machines delete", "delete machine: NAME", machinesDelete)
+	register("machines command", "send command to machine: NAME COMMAND", machinesCommand)
+	register("machines add-ip", "add IP to machine: NAME", machinesAddIP)
+	register("machines change-password", "change machine password, read from stdin if -password or -generate is not set: NAME", machinesChangePassword)
+	register("machines users", "list machine additional users: NAME", machinesUsers)
+	register("machines jobs", "list machine jobs: NAME", machinesJobs)
+	register("machines find-ip", "find machines owning IP address or IPs within CIDR: IP|CIDR", machinesFindIP)
+}
+
+// Parse flags and check number of positional args
+func parseArgs(fs *flag.FlagSet, args []string, names ...string) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != len(names) {
+		return nil, fmt.Errorf("%s: expected arguments: %s", fs.Name(), strings.Join(names, " "))
+	}
+	return fs.Args(), nil
+}
+
+func machinesList(a *app, args []string) error {
+	fs := newFlagSet(a, "machines list")
+	full := fs.Bool("full", false, "show full machines info")
+	status := fs.String("status", "", "show only running or stopped machines")
+	selector := fs.String("select", "", "show full info of machines matching selector, e.g. name=VPS0*,ip=10.0.0.0/8")
+	page := addPageFlags(fs)
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	if *selector != "" {
+		if *status != "" {
+			return fmt.Errorf("-status can't be used with -select")
+		}
+		sel, err := winvps.ParseSelector(*selector)
+		if err != nil {
+			return err
+		}
+		machines, err := a.client.SelectMachines(sel)
+		if err != nil {
+			return err
+		}
+		return a.print(machines)
+	}
+
+	if *full {
+		if *status != "" {
+			return fmt.Errorf("-status can't be used with -full")
+		}
+		machines, err := list(page, a.client.GetMachinesFull)
+		if err != nil {
+			return err
+		}
+		return a.print(machines)
+	}
+
+	fn := a.client.GetMachines
+	switch *status {
+	case "":
+	case "running":
+		fn = a.client.GetMachinesRunning
+	case "stopped":
+		fn = a.client.GetMachinesStopped
+	default:
+		return fmt.Errorf("allowed status 'running' or 'stopped' but '%s' passed", *status)
+	}
+	machines, err := list(page, fn)
+	if err != nil {
+		return err
+	}
+	return a.print(machines)
+}
+
+func machinesGet(a *app, args []string) error {
+	args, err := parseArgs(newFlagSet(a, "machines get"), args, "NAME")
+	if err != nil {
+		return err
+	}
+	machine, err := a.client.GetMachine(args[0])
+	if err != nil {
+		return err
+	}
+	return a.print(machine)
+}
+
+func machinesCreate(a *app, args []string) error {
+	fs := newFlagSet(a, "machines create")
+	opt := &winvps.CreateMachineOptions{}
+	fs.IntVar(&opt.ProductID, "product", 0, "product ID, profile default is used if not set")
+	fs.IntVar(&opt.TemplateID, "template", 0, "template ID, profile default is used if not set")
+	fs.IntVar(&opt.LocationID, "location", 0, "location ID, profile default is used if not set")
+	fs.IntVar(&opt.BrandID, "brand", 0, "brand ID")
+	fs.StringVar(&opt.Description, "description", "", "machine description")
+	fs.Var((*secretFlag)(&opt.Password), "password", "administrator password")
+	fs.StringVar(&opt.DiskType, "disk-type", "", "disk type, hdd or ssd")
+	fs.IntVar(&opt.AddDisk, "add-disk", 0, "additional disk size")
+	fs.IntVar(&opt.AddRam, "add-ram", 0, "additional RAM")
+	fs.IntVar(&opt.AddCpu, "add-cpu", 0, "additional CPU cores")
+	fs.IntVar(&opt.AddBand, "add-band", 0, "additional bandwidth")
+	fs.IntVar(&opt.AutoStart, "auto-start", 0, "start machine after creation, 1 to enable")
+	fs.IntVar(&opt.AddIPv6, "ipv6", 0, "add IPv6 address, 1 to enable")
+	fs.StringVar(&opt.UiLanguage, "ui-language", "", "windows UI language")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	a.profile.ApplyDefaults(opt)
+
+	name, jobs, err := a.client.CreateMachine(opt)
+	if err != nil {
+		return err
+	}
+	return a.print(struct {
+		Name string        `json:"name"`
+		Jobs []*winvps.Job `json:"jobs"`
+	}{name, jobs})
+}
+
+func machinesUpdate(a *app, args []string) error {
+	fs := newFlagSet(a, "machines update")
+	opt := &winvps.UpdateMachineOptions{}
+	fs.Var((*secretFlag)(&opt.Password), "password", "administrator password")
+	fs.IntVar(&opt.ProductID, "product", 0, "product ID")
+	fs.IntVar(&opt.AddDisk, "add-disk", 0, "additional disk size")
+	fs.IntVar(&opt.AddRam, "add-ram", 0, "additional RAM")
+	fs.IntVar(&opt.AddCpu, "add-cpu", 0, "additional CPU cores")
+	fs.IntVar(&opt.AddBand, "add-band", 0, "additional bandwidth")
+	args, err := parseArgs(fs, args, "NAME")
+	if err != nil {
+		return err
+	}
+
+	jobs, err := a.client.UpdateMachine(args[0], opt)
+	if err != nil {
+		return err
+	}
+	return a.print(jobs)
+}
+
+func machinesReinstall(a *app, args []string) error {
+	fs := newFlagSet(a, "machines reinstall")
+	opt := &winvps.ReinstallMachineOptions{}
+	fs.Var((*secretFlag)(&opt.Password), "password", "administrator password")
+	fs.IntVar(&opt.TemplateID, "template", 0, "template ID")
+	fs.IntVar(&opt.BrandID, "brand", 0, "brand ID")
+	fs.IntVar(&opt.AutoStart, "auto-start", 0, "start machine after reinstall, 1 to enable")
+	args, err := parseArgs(fs, args, "NAME")
+	if err != nil {
+		return err
+	}
+
+	jobs, err := a.client.ReinstallMachine(args[0], opt)
+	if err != nil {
+		return err
+	}
+	return a.print(jobs)
+}
+
+func machinesDelete(a *app, args []string) error {
+	args, err := parseArgs(newFlagSet(a, "machines delete"), args, "NAME")
+	if err != nil {
+		return err
+	}
+	jobs, err := a.client.DeleteMachine(args[0])
+	if err != nil {
+		return err
+	}
+	return a.print(jobs)
+}
+
+func machinesCommand(a *app, args []string) error {
+	args, err := parseArgs(newFlagSet(a, "machines command"), args, "NAME", "COMMAND")
+	if err != nil {
+		return err
+	}
+	jobs, err := a.client.SendMachineCommand(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	return a.print(jobs)
+}
+
+func machinesAddIP(a *app, args []string) error {
+	args, err := parseArgs(newFlagSet(a, "machines add-ip"), args, "NAME")
+	if err != nil {
+		return err
+	}
+	address, jobs, err := a.client.AddMachineIP(args[0])
+	if err != nil {
+		return err
+	}
+	return a.print(struct {
+		Address string        `json:"address"`
+		Jobs    []*winvps.Job `json:"jobs"`
+	}{address, jobs})
+}
+
+func machinesChangePassword(a *app, args []string) error {
+	fs := newFlagSet(a, "machines change-password")
+	password := fs.String("password", "", "new password, read from stdin if not set")
+	generate := fs.Bool("generate", false, "generate a new password and print it")
+	args, err := parseArgs(fs, args, "NAME")
+	if err != nil {
+		return err
+	}
+
+	if *generate {
+		if *password != "" {
+			return fmt.Errorf("-password can't be used with -generate")
+		}
+		if *password, err = winvps.GeneratePassword(nil); err != nil {
+			return err
+		}
+	} else if *password == "" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("unable to read password from stdin: %v", err)
+		}
+		*password = strings.TrimRight(line, "\r\n")
+	}
+	result, err := a.client.ChangeMachinePassword(args[0], *password)
+	if err != nil {
+		return err
+	}
+	out := struct {
+		Result   bool   `json:"result"`
+		Password string `json:"password,omitempty"`
+	}{Result: result}
+	// a password generated in dry-run mode is never applied
+	if *generate && !a.client.DryRunEnabled() {
+		out.Password = *password
+	}
+	return a.print(out)
+}
+
+func machinesUsers(a *app, args []string) error {
+	fs := newFlagSet(a, "machines users")
+	page := addPageFlags(fs)
+	args, err := parseArgs(fs, args, "NAME")
+	if err != nil {
+		return err
+	}
+	users, err := list(page, func(opts ...*winvps.RequestOptions) ([]*winvps.User, *winvps.Pagination, error) {
+		return a.client.GetMachineUsers(args[0], opts...)
+	})
+	if err != nil {
+		return err
+	}
+	return a.print(users)
+}
+
+func machinesJobs(a *app, args []string) error {
+	fs := newFlagSet(a, "machines jobs")
+	page := addPageFlags(fs)
+	args, err := parseArgs(fs, args, "NAME")
+	if err != nil {
+		return err
+	}
+	jobs, err := list(page, func(opts ...*winvps.RequestOptions) ([]*winvps.Job, *winvps.Pagination, error) {
+		return a.client.GetMachineJobs(args[0], opts...)
+	})
+	if err != nil {
+		return err
+	}
+	return a.print(jobs)
+}
+
+func machinesFindIP(a *app, args []string) error {
+	args, err := parseArgs(newFlagSet(a, "machines find-ip"), args, "IP|CIDR")
+	if err != nil {
+		return err
+	}
+	matches, err := winvps.NewIPIndex(a.client, 0).Search(args[0])
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		return fmt.Errorf("no machines found for %s", args[0])
+	}
+	return a.print(matches)
+}
@@ -0,0 +1,142 @@
+// Command winvps is a command-line tool for the winvps api.
+//
+// Usage:
+//
+//	winvps [global flags] <command> [subcommand] [flags] [args]
+//
+// Results are printed as a table by default, -output flag selects json, ndjson, yaml,
+// csv or go template output, -columns and -sort flags select and order columns.
+//
+// Settings are taken from the -token and -base-url flags, WINVPS_* env variables
+// and the profile selected by -profile flag from the profiles file, in that order.
+// Run "winvps help" for the list of commands.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fozzyhosting/winvps-go-client"
+	"github.com/fozzyhosting/winvps-go-client/render"
+)
+
+// Represents a single cli command
+type command struct {
+	usage string
+	run   func(a *app, args []string) error
+	// Command works without the api, so no profile or client is set up
+	offline bool
+}
+
+// Holds state shared by all commands
+type app struct {
+	client  *winvps.Client
+	profile *winvps.Profile
+	out     io.Writer
+	render  *render.Options
+}
+
+// Top level commands, commands with subcommands are keyed as "group subcommand"
+var commands = map[string]*command{}
+
+func register(name, usage string, run func(a *app, args []string) error) {
+	commands[name] = &command{usage: usage, run: run}
+}
+
+// Register a command working without the api, e.g. on local files. It runs without api token
+// and its app has neither profile nor client
+func registerOffline(name, usage string, run func(a *app, args []string) error) {
+	commands[name] = &command{usage: usage, run: run, offline: true}
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "winvps:", err)
+		os.Exit(1)
+	}
+}
+
+// Parse global flags, find and run the command
+func run(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("winvps", flag.ContinueOnError)
+	fs.SetOutput(out)
+	token := fs.String("token", "", "api token")
+	configPath := fs.String("config", "", "profiles file path (default "+winvps.DefaultProfilesPath()+")")
+	profile := fs.String("profile", "", "profile name, WINVPS_PROFILE env or default profile from the file is used by default")
+	baseURL := fs.String("base-url", "", "api base url")
+	dryRun := fs.Bool("dry-run", false, "don't send mutating requests, print them instead")
+	auditPath := fs.String("audit", "", "append mutating calls to the audit journal file")
+	actor := fs.String("actor", os.Getenv("USER"), "actor recorded in the audit journal")
+	guardOpts := addGuardFlags(fs)
+	renderOpts := addRenderFlags(fs)
+	fs.Usage = func() { printUsage(fs) }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+	if len(args) == 0 || args[0] == "help" {
+		printUsage(fs)
+		return nil
+	}
+
+	cmd, args := findCommand(args)
+	if cmd == nil {
+		return fmt.Errorf("unknown command %q, run \"winvps help\" for usage", strings.Join(args, " "))
+	}
+
+	a := &app{out: out, render: renderOpts}
+	if cmd.offline {
+		return cmd.run(a, args)
+	}
+	p, err := loadProfile(*configPath, *profile, *token, *baseURL)
+	if err != nil {
+		return err
+	}
+	opts := []winvps.Option{winvps.DryRun(*dryRun), winvps.Guard(guardOpts.options(out))}
+	if *auditPath != "" {
+		opts = append(opts, winvps.Audit(winvps.AuditFile(*auditPath), *actor))
+	}
+	client, err := p.NewClient(opts...)
+	if err != nil {
+		return err
+	}
+	a.client, a.profile = client, p
+	err = cmd.run(a, args)
+	for _, r := range client.DryRunRequests() {
+		fmt.Fprintf(out, "dry-run: %s %s %s\n", r.Method, r.URL, r.Body)
+	}
+	return err
+}
+
+// Returns a command matching args and remaining args
+func findCommand(args []string) (*command, []string) {
+	if len(args) >= 2 {
+		if cmd, ok := commands[args[0]+" "+args[1]]; ok {
+			return cmd, args[2:]
+		}
+	}
+	if cmd, ok := commands[args[0]]; ok {
+		return cmd, args[1:]
+	}
+	return nil, args
+}
+
+func printUsage(fs *flag.FlagSet) {
+	out := fs.Output()
+	fmt.Fprintln(out, "Usage: winvps [global flags] <command> [flags] [args]")
+	fmt.Fprintln(out, "\nCommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-26s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintln(out, "\nGlobal flags:")
+	fs.PrintDefaults()
+}
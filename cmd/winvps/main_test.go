@@ -0,0 +1,530 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fozzyhosting/winvps-go-client"
+	"github.com/stretchr/testify/require"
+)
+
+// returns body from http.Request as string
+func getBody(t *testing.T, r *http.Request) string {
+	data, err := io.ReadAll(r.Body)
+	require.NoError(t, err)
+	return string(data)
+}
+
+// setup a test http server and returns func running the cli against it
+func setup(t *testing.T) (*http.ServeMux, func(args ...string) (string, error)) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	for _, env := range []string{"WINVPS_PROFILE", "WINVPS_TOKEN", "WINVPS_BASE_URL", "WINVPS_SECRETS_PASSPHRASE"} {
+		t.Setenv(env, "")
+	}
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	profiles := "default: test\nprofiles:\n  test:\n    token: secret\n    base_url: " + server.URL + "\n    product_id: 1\n"
+	require.NoError(t, os.WriteFile(config, []byte(profiles), 0600))
+
+	return mux, func(args ...string) (string, error) {
+		out := new(bytes.Buffer)
+		err := run(append([]string{"-config", config}, args...), out)
+		return out.String(), err
+	}
+}
+
+// Runs the cli without api token
+func runOffline(t *testing.T, out io.Writer, args ...string) error {
+	t.Setenv("WINVPS_TOKEN", "")
+	t.Setenv("WINVPS_PROFILE", "")
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(config, []byte("profiles: {}\n"), 0600))
+	return run(append([]string{"-config", config}, args...), out)
+}
+
+func TestMachinesList(t *testing.T) {
+	mux, run := setup(t)
+
+	mux.HandleFunc("/api/v2/machines/stopped", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "secret", r.Header.Get("API-KEY"))
+		require.Equal(t, "limit=1&page=2", r.URL.RawQuery)
+		w.Write([]byte(`{"data":[{"name":"VPS01","status":"Stopped"}],"pagination":{"total":2,"limit":1,"page":2,"pages":2}}`))
+	})
+
+	out, err := run("-output", "json", "machines", "list", "-status", "stopped", "-limit", "1", "-page", "2")
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"name":"VPS01","status":"Stopped","notes":""}]`, out)
+
+	_, err = run("machines", "list", "-status", "paused")
+	require.EqualError(t, err, "allowed status 'running' or 'stopped' but 'paused' passed")
+}
+
+func TestMachinesCommand(t *testing.T) {
+	mux, run := setup(t)
+
+	mux.HandleFunc("/api/v2/machines/VPS01/restart", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		w.Write([]byte(`{"data":{"jobs":[{"id":1,"status":"Pending"}]}}`))
+	})
+
+	out, err := run("-columns", "id,status", "machines", "command", "VPS01", "restart")
+	require.NoError(t, err)
+	require.Equal(t, "ID  STATUS\n1   Pending\n", out)
+
+	_, err = run("machines", "command", "VPS01")
+	require.EqualError(t, err, "machines command: expected arguments: NAME COMMAND")
+}
+
+func TestJobsWait(t *testing.T) {
+	mux, run := setup(t)
+
+	mux.HandleFunc("/api/v2/jobs/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"id":1,"status":"Complete"}}`))
+	})
+
+	out, err := run("-output", "template", "-go-template", "{{.ID}} {{.Status}}", "jobs", "wait", "-interval", "1ms", "1")
+	require.NoError(t, err)
+	require.Equal(t, "1 Complete\n", out)
+
+	_, err = run("jobs", "wait", "-interval", "0", "1")
+	require.EqualError(t, err, "-interval must be positive")
+
+	_, err = run("jobs", "get", "abc")
+	require.EqualError(t, err, `invalid job ID "abc"`)
+}
+
+func TestUnknownCommand(t *testing.T) {
+	_, run := setup(t)
+
+	_, err := run("machines", "explode")
+	require.EqualError(t, err, `unknown command "machines explode", run "winvps help" for usage`)
+}
+
+func TestMachinesCreate(t *testing.T) {
+	mux, run := setup(t)
+
+	mux.HandleFunc("/api/v2/machines", func(w http.ResponseWriter, r *http.Request) {
+		require.JSONEq(t, `{"product_id":1,"template_id":2,"location_id":3}`, getBody(t, r))
+		w.Write([]byte(`{"data":{"name":"VPS01","jobs":[{"id":1}]}}`))
+	})
+
+	out, err := run("-output", "template", "-go-template", "{{.Name}}", "machines", "create", "-template", "2", "-location", "3")
+	require.NoError(t, err)
+	require.Equal(t, "VPS01\n", out)
+}
+
+func TestLoadProfile(t *testing.T) {
+	t.Setenv("WINVPS_PROFILE", "")
+	t.Setenv("WINVPS_TOKEN", "")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("profiles:\n  default:\n    token: file\n"), 0600))
+
+	p, err := loadProfile(path, "", "", "")
+	require.NoError(t, err)
+	require.Equal(t, "file", p.Token)
+
+	t.Setenv("WINVPS_TOKEN", "env")
+	p, err = loadProfile(path, "", "", "")
+	require.NoError(t, err)
+	require.Equal(t, "env", p.Token)
+
+	p, err = loadProfile(path, "", "flag", "")
+	require.NoError(t, err)
+	require.Equal(t, "flag", p.Token)
+
+	_, err = loadProfile(path, "staging", "", "")
+	require.EqualError(t, err, "profile 'staging' not found")
+
+	_, err = loadProfile(filepath.Join(t.TempDir(), "missing.yaml"), "", "flag", "")
+	require.Error(t, err)
+}
+
+func TestFleetPlan(t *testing.T) {
+	mux, run := setup(t)
+
+	mux.HandleFunc("/api/v2/machines/full", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[],"pagination":{"total":0,"limit":50,"page":1,"pages":1}}`))
+	})
+	mux.HandleFunc("/api/v2/products", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"id":1,"limits":{}}],"pagination":{"total":1,"limit":50,"page":1,"pages":1}}`))
+	})
+
+	spec := filepath.Join(t.TempDir(), "spec.yaml")
+	require.NoError(t, os.WriteFile(spec, []byte("groups:\n  - name: db\n    product_id: 1\n    template_id: 1\n    location_id: 1\n"), 0600))
+
+	out, err := run("fleet", "plan", spec)
+	require.NoError(t, err)
+	require.Equal(t, "+ create db\n\nPlan: 1 to create, 0 to reinstall, 0 to update, 0 to start, 0 to stop, 0 to delete.\n", out)
+}
+
+func TestFleetDrift(t *testing.T) {
+	mux, run := setup(t)
+
+	mux.HandleFunc("/api/v2/machines/full", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"name":"VPS01","status":"Stopped"}],"pagination":{"total":1,"limit":50,"page":1,"pages":1}}`))
+	})
+
+	desired := filepath.Join(t.TempDir(), "desired.yaml")
+	require.NoError(t, os.WriteFile(desired, []byte("VPS01:\n  status: Running\n"), 0600))
+
+	out, err := run("fleet", "drift", desired)
+	require.EqualError(t, err, "drift detected in 1 machines")
+	require.Equal(t, "VPS01:\n    status: Stopped, desired Running\n1 of 1 machines drifted\n", out)
+}
+
+func TestMachinesBulkCommand(t *testing.T) {
+	mux, run := setup(t)
+
+	mux.HandleFunc("/api/v2/machines/full", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"name":"VPS01","status":"Running"},{"name":"VPS02","status":"Stopped"}],"pagination":{"total":2,"limit":50,"page":1,"pages":1}}`))
+	})
+	mux.HandleFunc("/api/v2/machines/VPS02/start", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"jobs":[{"id":1,"status":"Pending"}]}}`))
+	})
+
+	out, err := run("-columns", "machine,success,error", "machines", "bulk-command", "-status", "stopped", "start")
+	require.NoError(t, err)
+	require.Equal(t, "MACHINE  SUCCESS  ERROR\nVPS02    true     \n", out)
+
+	out, err = run("-columns", "machine,success", "machines", "bulk-command", "-select", "name=VPS0*,status=stopped", "start")
+	require.NoError(t, err)
+	require.Equal(t, "MACHINE  SUCCESS\nVPS02    true\n", out)
+
+	_, err = run("machines", "bulk-command", "-select", "color=red", "start")
+	require.EqualError(t, err, "unknown selector key 'color'")
+
+	_, err = run("machines", "bulk-command", "start")
+	require.EqualError(t, err, "no machines selected, use -names, -all, -status or -select")
+}
+
+func TestMachinesRollout(t *testing.T) {
+	mux, run := setup(t)
+
+	mux.HandleFunc("/api/v2/machines/VPS01", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"name":"VPS01","status":"Running"}}`))
+	})
+	mux.HandleFunc("/api/v2/machines/VPS01/restart", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"jobs":[{"id":1,"status":"Pending"}]}}`))
+	})
+	mux.HandleFunc("/api/v2/jobs/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"id":1,"status":"Complete"}}`))
+	})
+	mux.HandleFunc("/api/v2/machines/VPS02/restart", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"not found"}`))
+	})
+
+	out, err := run("machines", "rollout", "-names", "VPS01,VPS02", "-interval", "1ms", "restart")
+	require.EqualError(t, err, "rollout halted, 1 machines failed, allowed 0")
+	require.Equal(t, "VPS01 done\nVPS02 failed: status: 404, error: not found\n", out)
+}
+
+func TestMachinesCompliance(t *testing.T) {
+	mux, run := setup(t)
+
+	mux.HandleFunc("/api/v2/machines/full", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"name":"VPS01","status":"Running","os":{"template_id":"1","update_status":{"result_code":2,"reboot_required":true,"update_time":"2099-01-01 00:00:00"}}}],"pagination":{"total":1,"limit":50,"page":1,"pages":1}}`))
+	})
+
+	out, err := run("-columns", "machine,result,issues", "machines", "compliance")
+	require.EqualError(t, err, "1 of 1 machines not compliant")
+	require.Equal(t, "MACHINE  RESULT     ISSUES\nVPS01    succeeded  reboot required\n", out)
+
+	out, err = run("-columns", "template_id,machines,reboot_required", "machines", "compliance", "-summary")
+	require.Error(t, err)
+	require.Equal(t, "TEMPLATE_ID  MACHINES  REBOOT_REQUIRED\n1            1         1\n", out)
+}
+
+func TestMachinesFindIP(t *testing.T) {
+	mux, run := setup(t)
+
+	mux.HandleFunc("/api/v2/machines/full", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"name":"VPS01","status":"Running","ips":[{"version":4,"address":"10.0.0.1"}]}],"pagination":{"total":1,"limit":50,"page":1,"pages":1}}`))
+	})
+
+	out, err := run("-columns", "ip,machine.name,machine.status", "machines", "find-ip", "10.0.0.1:3389")
+	require.NoError(t, err)
+	require.Equal(t, "IP        MACHINE.NAME  MACHINE.STATUS\n10.0.0.1  VPS01         Running\n", out)
+
+	_, err = run("machines", "find-ip", "192.168.0.0/16")
+	require.EqualError(t, err, "no machines found for 192.168.0.0/16")
+}
+
+func TestMachinesRDP(t *testing.T) {
+	mux, run := setup(t)
+
+	mux.HandleFunc("/api/v2/machines/VPS01", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"name":"VPS01","status":"Running","ips":[{"version":4,"address":"10.0.0.1"}]}}`))
+	})
+	mux.HandleFunc("/api/v2/machines/VPS01/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"username":"admin","role":"admin"}],"pagination":{"total":1,"limit":50,"page":1,"pages":1}}`))
+	})
+
+	out, err := run("machines", "rdp", "-remmina", "-width", "1024", "-height", "768", "VPS01")
+	require.NoError(t, err)
+	require.Contains(t, out, "server=10.0.0.1\nusername=admin\n")
+	require.Contains(t, out, "resolution_width=1024\n")
+
+	dir := t.TempDir()
+	out, err = run("machines", "rdp-export", "-dir", dir, "-names", "VPS01", "-username", "Administrator")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "VPS01.rdp")+"\n", out)
+	data, err := os.ReadFile(filepath.Join(dir, "VPS01.rdp"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "username:s:Administrator\r\n")
+
+	mux.HandleFunc("/api/v2/machines/VPS02", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"name":"VPS02","status":"Running","ips":[]}}`))
+	})
+	out, err = run("machines", "rdp-export", "-dir", dir, "-names", "VPS02,VPS01", "-username", "Administrator")
+	require.EqualError(t, err, "1 of 2 machines skipped")
+	require.Equal(t, "skipped VPS02: machine VPS02 has no IPv4 address\n"+filepath.Join(dir, "VPS01.rdp")+"\n", out)
+
+	_, err = run("machines", "rdp-export", "-all")
+	require.EqualError(t, err, "-dir is required")
+}
+
+func TestInventory(t *testing.T) {
+	mux, run := setup(t)
+
+	mux.HandleFunc("/api/v2/machines/full", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"name":"VPS01","status":"Running","ips":[{"version":4,"address":"10.0.0.1"}]}],"pagination":{"total":1,"limit":50,"page":1,"pages":1}}`))
+	})
+	mux.HandleFunc("/api/v2/products", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[],"pagination":{"total":0,"limit":50,"page":1,"pages":1}}`))
+	})
+
+	out, err := run("inventory", "-format", "ini", "-vars", "ansible_connection=winrm")
+	require.NoError(t, err)
+	require.Equal(t, `VPS01 ansible_host=10.0.0.1 winvps_ipv4='["10.0.0.1"]' winvps_ipv6='[]' winvps_notes="" winvps_product=custom winvps_status=Running
+
+[all:vars]
+ansible_connection=winrm
+
+[product_custom]
+VPS01
+
+[status_running]
+VPS01
+`, out)
+
+	out, err = run("inventory", "--host", "VPS01")
+	require.NoError(t, err)
+	require.Contains(t, out, `"ansible_host": "10.0.0.1"`)
+
+	_, err = run("inventory", "-format", "toml")
+	require.EqualError(t, err, "allowed format 'yaml' or 'ini' but 'toml' passed")
+}
+
+func TestPassword(t *testing.T) {
+	mux, run := setup(t)
+
+	out, err := run("password", "-length", "20", "-no-symbols")
+	require.NoError(t, err)
+	require.Len(t, out, 21)
+	require.NoError(t, winvps.ValidatePassword(out[:20]))
+
+	// works without api token
+	b := new(bytes.Buffer)
+	require.NoError(t, runOffline(t, b, "password"))
+	require.NoError(t, winvps.ValidatePassword(strings.TrimSpace(b.String())))
+	require.ErrorContains(t, runOffline(t, b, "machines", "list"), "api token is required")
+
+	var sent string
+	mux.HandleFunc("/api/v2/machines/VPS01/change_password", func(w http.ResponseWriter, r *http.Request) {
+		sent = getBody(t, r)
+		w.Write([]byte(`{"data":{"result":true}}`))
+	})
+	out, err = run("-output", "json", "machines", "change-password", "-generate", "VPS01")
+	require.NoError(t, err)
+	var result struct {
+		Result   bool   `json:"result"`
+		Password string `json:"password"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	require.True(t, result.Result)
+	require.JSONEq(t, `{"password":"`+result.Password+`"}`, sent)
+
+	_, err = run("machines", "change-password", "-password", "weak", "VPS01")
+	require.EqualError(t, err, "password length must be between 8 and 127")
+}
+
+func TestMachinesRotatePasswords(t *testing.T) {
+	mux, run := setup(t)
+
+	mux.HandleFunc("/api/v2/machines/VPS01/change_password", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"result":true}}`))
+	})
+
+	dir := t.TempDir()
+	secrets, state := filepath.Join(dir, "secrets"), filepath.Join(dir, "state")
+	out, err := run("-columns", "machine,success", "machines", "rotate-passwords", "-names", "VPS01", "-out", secrets, "-state", state)
+	require.NoError(t, err)
+	require.Equal(t, "MACHINE  SUCCESS\nVPS01    true\n", out)
+	data, err := os.ReadFile(secrets)
+	require.NoError(t, err)
+	require.Regexp(t, "^VPS01\t.{16}\tpending\nVPS01\t.{16}\n$", string(data))
+
+	out, err = run("machines", "rotate-passwords", "-names", "VPS01", "-out", "-", "-state", state)
+	require.NoError(t, err)
+	require.Empty(t, out)
+
+	_, err = run("machines", "rotate-passwords", "-names", "VPS01")
+	require.EqualError(t, err, "-out or -store is required")
+}
+
+func TestSecrets(t *testing.T) {
+	mux, run := setup(t)
+
+	mux.HandleFunc("/api/v2/machines/VPS01/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"username":"admin","role":"admin","password":"Secret-1"}],"pagination":{"total":1,"limit":50,"page":1,"pages":1}}`))
+	})
+	mux.HandleFunc("/api/v2/machines/VPS01/change_password", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"result":true}}`))
+	})
+
+	store := filepath.Join(t.TempDir(), "secrets.enc")
+	_, err := run("secrets", "list", "-store", store)
+	require.EqualError(t, err, "secrets passphrase is required, use WINVPS_SECRETS_PASSPHRASE env")
+
+	t.Setenv("WINVPS_SECRETS_PASSPHRASE", "passphrase")
+	out, err := run("secrets", "import", "-store", store, "VPS01")
+	require.NoError(t, err)
+	require.Equal(t, "1 users stored\n", out)
+
+	out, err = run("secrets", "get", "-store", store, "-user", "admin", "VPS01")
+	require.NoError(t, err)
+	require.Equal(t, "Secret-1\n", out)
+
+	_, err = run("machines", "rotate-passwords", "-names", "VPS01", "-store", store)
+	require.NoError(t, err)
+	out, err = run("secrets", "list", "-store", store)
+	require.NoError(t, err)
+	require.Equal(t, "MACHINE  USERNAME\nVPS01    Administrator\nVPS01    admin\n", out)
+
+	// local store is read without api token
+	b := new(bytes.Buffer)
+	require.NoError(t, runOffline(t, b, "secrets", "get", "-store", store, "-user", "admin", "VPS01"))
+	require.Equal(t, "Secret-1\n", b.String())
+	b.Reset()
+	require.NoError(t, runOffline(t, b, "secrets", "list", "-store", store))
+	require.Equal(t, "MACHINE  USERNAME\nVPS01    Administrator\nVPS01    admin\n", b.String())
+
+	_, err = run("machines", "rotate-passwords", "-names", "VPS01", "-store", store, "-out", "-")
+	require.EqualError(t, err, "-out can't be used with -store")
+}
+
+func TestDryRun(t *testing.T) {
+	mux, run := setup(t)
+
+	mux.HandleFunc("/api/v2/machines/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("mutating request must not be sent: %s %s", r.Method, r.URL)
+	})
+
+	out, err := run("-dry-run", "-columns", "result", "machines", "change-password", "-password", "Secret-123", "VPS01")
+	require.NoError(t, err)
+	require.Equal(t, "RESULT\ntrue\ndry-run: POST /api/v2/machines/VPS01/change_password {\"password\":\"[REDACTED]\"}\n", out)
+}
+
+func TestAudit(t *testing.T) {
+	mux, run := setup(t)
+	journal := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	mux.HandleFunc("/api/v2/machines/VPS01/restart", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"jobs":[{"id":7,"status":"Pending"}]}}`)
+	})
+	mux.HandleFunc("/api/v2/machines/VPS02/restart", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"machine not found"}`)
+	})
+
+	_, err := run("-audit", journal, "-actor", "alice", "machines", "command", "VPS01", "restart")
+	require.NoError(t, err)
+	_, err = run("-audit", journal, "-actor", "bob", "machines", "command", "VPS02", "restart")
+	require.Error(t, err)
+
+	out, err := run("-columns", "actor,operation,machine,jobs", "audit", journal)
+	require.NoError(t, err)
+	require.Equal(t, "ACTOR  OPERATION           MACHINE  JOBS\nalice  SendMachineCommand  VPS01    7\nbob    SendMachineCommand  VPS02    \n", out)
+
+	out, err = run("-columns", "actor,error", "audit", "-failed", journal)
+	require.NoError(t, err)
+	require.Equal(t, "ACTOR  ERROR\nbob    status: 404, error: machine not found\n", out)
+
+	// journal is read without api token
+	b := new(bytes.Buffer)
+	require.NoError(t, runOffline(t, b, "-columns", "actor", "audit", "-machine", "VPS01", journal))
+	require.Equal(t, "ACTOR\nalice\n", b.String())
+}
+
+func TestGuard(t *testing.T) {
+	mux, run := setup(t)
+
+	mux.HandleFunc("/api/v2/machines/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("denied request must not be sent: %s %s", r.Method, r.URL)
+	})
+
+	_, err := run("-deny", "PROD*,DB*", "machines", "delete", "PROD01")
+	require.EqualError(t, err, `DeleteMachine of PROD01 denied: matches deny pattern "PROD*"`)
+	_, err = run("-allow", "TEST*", "machines", "reinstall", "-template", "1", "VPS01")
+	require.EqualError(t, err, "ReinstallMachine of VPS01 denied: doesn't match allow patterns")
+}
+
+func TestJobsTree(t *testing.T) {
+	mux, run := setup(t)
+
+	mux.HandleFunc("/api/v2/machines/VPS01/jobs", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"id":2,"parent_id":1,"type":"Change","status":"Failed"},{"id":1,"parent_id":1,"type":"Initialize","status":"Complete"},{"id":3,"parent_id":0,"type":"Restart","status":"Complete"}],"pagination":{"total":3,"limit":50,"page":1,"pages":1}}`)
+	})
+
+	out, err := run("jobs", "tree", "-machine", "VPS01")
+	require.NoError(t, err)
+	require.Equal(t, "1 Initialize Complete [group Failed]\n└── 2 Change Failed\n3 Restart Complete\n", out)
+
+	out, err = run("jobs", "tree", "-machine", "VPS01", "-id", "2")
+	require.NoError(t, err)
+	require.Equal(t, "2 Change Failed\n", out)
+
+	_, err = run("jobs", "tree", "-machine", "VPS01", "-id", "9")
+	require.EqualError(t, err, "job 9 not found")
+}
+
+func TestDryRunRotatePasswords(t *testing.T) {
+	mux, run := setup(t)
+
+	mux.HandleFunc("/api/v2/machines/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("mutating request must not be sent: %s %s", r.Method, r.URL)
+	})
+
+	dir := t.TempDir()
+	passwords, state := filepath.Join(dir, "passwords"), filepath.Join(dir, "state")
+	out, err := run("-dry-run", "-columns", "machine,success", "machines", "rotate-passwords", "-names", "VPS01", "-out", passwords, "-state", state)
+	require.NoError(t, err)
+	require.Equal(t, "MACHINE  SUCCESS\nVPS01    true\ndry-run: POST /api/v2/machines/VPS01/change_password {\"password\":\"[REDACTED]\"}\n", out)
+	require.NoFileExists(t, passwords)
+	require.NoFileExists(t, state)
+
+	out, err = run("-dry-run", "-output", "json", "machines", "change-password", "-generate", "VPS01")
+	require.NoError(t, err)
+	require.Equal(t, "{\n  \"result\": true\n}\ndry-run: POST /api/v2/machines/VPS01/change_password {\"password\":\"[REDACTED]\"}\n", out)
+}
+
+func TestMachinesRolloutFlags(t *testing.T) {
+	_, run := setup(t)
+
+	for _, flag := range []string{"-concurrency", "-wait"} {
+		_, err := run("machines", "rollout", flag, "1", "-names", "VPS01", "restart")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "flag provided but not defined: "+flag)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/fozzyhosting/winvps-go-client"
+	"github.com/fozzyhosting/winvps-go-client/render"
+)
+
+// Print v according to output flags
+func (a *app) print(v interface{}) error {
+	return render.Render(a.out, v, a.render)
+}
+
+// Represents comma separated list flag
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(s string) error {
+	*l = nil
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// Represents a flag holding a secret, its value is redacted when printed
+type secretFlag winvps.Secret
+
+func (f *secretFlag) String() string {
+	return winvps.Secret(*f).String()
+}
+
+func (f *secretFlag) Set(s string) error {
+	*f = secretFlag(s)
+	return nil
+}
+
+// Add output flags to the global flag set
+func addRenderFlags(fs *flag.FlagSet) *render.Options {
+	opt := &render.Options{}
+	fs.StringVar(&opt.Format, "output", render.Table, "output format: "+strings.Join(render.Formats, ", "))
+	fs.Var((*listFlag)(&opt.Columns), "columns", "comma separated columns to show, e.g. name,status,config.cpu_cores")
+	fs.StringVar(&opt.SortBy, "sort", "", "column to sort by, prefix with - for descending order")
+	fs.StringVar(&opt.Template, "go-template", "", "go template for template output, e.g. '{{.Name}}'")
+	return opt
+}
+
+// Represents pagination flags of list commands
+type pageFlags struct {
+	limit int
+	page  int
+}
+
+func addPageFlags(fs *flag.FlagSet) *pageFlags {
+	p := &pageFlags{}
+	fs.IntVar(&p.limit, "limit", 0, "page size")
+	fs.IntVar(&p.page, "page", 0, "fetch a single page, all pages are fetched by default")
+	return p
+}
+
+// Calls list method fn for a single page if requested, otherwise for all pages
+func list[T any](p *pageFlags, fn func(opts ...*winvps.RequestOptions) ([]T, *winvps.Pagination, error)) ([]T, error) {
+	if p.page > 0 {
+		items, _, err := fn(&winvps.RequestOptions{Limit: p.limit, Page: p.page})
+		return items, err
+	}
+	return winvps.ListAll(func(opts ...*winvps.RequestOptions) ([]T, *winvps.Pagination, error) {
+		for _, o := range opts {
+			o.Limit = p.limit
+		}
+		return fn(opts...)
+	})
+}
+
+// Create flag set for command which reports errors instead of exiting
+func newFlagSet(a *app, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.out)
+	return fs
+}
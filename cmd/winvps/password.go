@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/fozzyhosting/winvps-go-client"
+)
+
+func init() {
+	registerOffline("password", "generate a password satisfying Windows complexity requirements: [-length N] [-no-symbols] [-exclude CHARS]", passwordCommand)
+}
+
+func passwordCommand(a *app, args []string) error {
+	fs := newFlagSet(a, "password")
+	opt := &winvps.PasswordOptions{Lower: true, Upper: true, Digits: true}
+	fs.IntVar(&opt.Length, "length", 16, "password length")
+	noSymbols := fs.Bool("no-symbols", false, "don't use symbols")
+	fs.StringVar(&opt.Exclude, "exclude", "", "characters never used, e.g. 0O1lI")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	opt.Symbols = !*noSymbols
+	password, err := winvps.GeneratePassword(opt)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(a.out, password)
+	return err
+}
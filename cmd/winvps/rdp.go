@@ -0,0 +1,85 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/fozzyhosting/winvps-go-client/rdp"
+)
+
+func init() {
+	register("machines rdp", "print RDP connection file of machine: [-remmina] [flags] NAME", machinesRDP)
+	register("machines rdp-export", "write RDP connection files of many machines: -dir DIR [-names|-all|-status|-select] [flags]", machinesRDPExport)
+}
+
+func addRDPFlags(fs *flag.FlagSet) *rdp.Options {
+	opt := &rdp.Options{}
+	fs.StringVar(&opt.Username, "username", "", "username, first admin user of the machine by default")
+	fs.IntVar(&opt.Port, "port", rdp.DefaultPort, "RDP port")
+	fs.IntVar(&opt.Width, "width", 0, "window width, full screen by default")
+	fs.IntVar(&opt.Height, "height", 0, "window height, full screen by default")
+	fs.BoolVar(&opt.MultiMonitor, "multimon", false, "use all monitors")
+	fs.BoolVar(&opt.Clipboard, "clipboard", true, "share clipboard")
+	fs.BoolVar(&opt.Drives, "drives", false, "share local drives")
+	fs.BoolVar(&opt.Printers, "printers", false, "share local printers")
+	return opt
+}
+
+func machinesRDP(a *app, args []string) error {
+	fs := newFlagSet(a, "machines rdp")
+	opt := addRDPFlags(fs)
+	remmina := fs.Bool("remmina", false, "print Remmina profile instead")
+	args, err := parseArgs(fs, args, "NAME")
+	if err != nil {
+		return err
+	}
+	m, err := a.client.GetMachine(args[0])
+	if err != nil {
+		return err
+	}
+	conn, err := rdp.Connect(a.client, m, opt)
+	if err != nil {
+		return err
+	}
+	if *remmina {
+		return conn.WriteRemmina(a.out, opt)
+	}
+	return conn.WriteRDP(a.out, opt)
+}
+
+func machinesRDPExport(a *app, args []string) error {
+	fs := newFlagSet(a, "machines rdp-export")
+	s := addSelectFlags(fs)
+	opt := addRDPFlags(fs)
+	dir := fs.String("dir", "", "directory to write files to")
+	fs.BoolVar(&opt.Remmina, "remmina", false, "write Remmina profiles too")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if *dir == "" {
+		return fmt.Errorf("-dir is required")
+	}
+	machines, err := s.machinesFull(a)
+	if err != nil {
+		return err
+	}
+	exports, err := rdp.WriteDir(a.client, machines, *dir, opt)
+	if err != nil {
+		return err
+	}
+	skipped := 0
+	for _, e := range exports {
+		if e.Err != nil {
+			skipped++
+			fmt.Fprintf(a.out, "skipped %s: %v\n", e.Machine, e.Err)
+			continue
+		}
+		for _, path := range e.Paths {
+			fmt.Fprintln(a.out, path)
+		}
+	}
+	if skipped > 0 {
+		return fmt.Errorf("%d of %d machines skipped", skipped, len(exports))
+	}
+	return nil
+}
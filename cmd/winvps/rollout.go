@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fozzyhosting/winvps-go-client"
+)
+
+func init() {
+	register("machines rollout", "rolling restart or updates with canaries: [-names|-all|-status|-select] restart|run_updates_install", machinesRollout)
+}
+
+func machinesRollout(a *app, args []string) error {
+	fs := newFlagSet(a, "machines rollout")
+	s := addSelectFlags(fs)
+	opt := &winvps.RolloutOptions{}
+	fs.DurationVar(&opt.Interval, "interval", 5*time.Second, "jobs poll interval")
+	fs.DurationVar(&opt.Timeout, "timeout", 0, "maximum wait for jobs of a single machine, 0 means wait forever")
+	fs.IntVar(&opt.Canaries, "canaries", 1, "number of machines processed one by one before batches")
+	fs.IntVar(&opt.BatchSize, "batch", 1, "number of machines processed at once after canaries")
+	fs.DurationVar(&opt.Pause, "pause", 0, "pause between batches")
+	fs.IntVar(&opt.MaxFailures, "max-failures", 0, "maximum number of failed machines before halting")
+	args, err := parseArgs(fs, args, "COMMAND")
+	if err != nil {
+		return err
+	}
+	names, err := s.machines(a)
+	if err != nil {
+		return err
+	}
+	opt.Progress = func(batch winvps.BulkResults) {
+		for _, r := range batch {
+			status := "done"
+			switch {
+			case r.Skipped:
+				status = "skipped: protected"
+			case !r.Success:
+				status = "failed: " + r.Error
+			}
+			fmt.Fprintf(a.out, "%s %s\n", r.Machine, status)
+		}
+	}
+	_, err = a.client.Rollout(names, args[0], opt)
+	return err
+}
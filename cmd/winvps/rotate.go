@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/fozzyhosting/winvps-go-client"
+	"github.com/fozzyhosting/winvps-go-client/secrets"
+)
+
+func init() {
+	register("machines rotate-passwords", "rotate administrator passwords, new ones are written to -out or -store: [-names|-all|-status|-select] -out FILE|-|-store PATH [-state FILE]", machinesRotatePasswords)
+}
+
+func machinesRotatePasswords(a *app, args []string) error {
+	fs := newFlagSet(a, "machines rotate-passwords")
+	s := addSelectFlags(fs)
+	out := fs.String("out", "", "file new passwords are appended to, - for stdout")
+	store := fs.String("store", "", "encrypted secrets file new passwords are stored to, passphrase is read from WINVPS_SECRETS_PASSPHRASE env")
+	opt := &winvps.RotateOptions{Password: &winvps.PasswordOptions{}}
+	fs.StringVar(&opt.StateFile, "state", "", "file of rotated machines used to resume interrupted rotation")
+	fs.IntVar(&opt.Password.Length, "length", 16, "password length")
+	fs.IntVar(&opt.Concurrency, "concurrency", 10, "maximum number of machines processed at once")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if *out != "" && *store != "" {
+		return fmt.Errorf("-out can't be used with -store")
+	}
+	switch *out {
+	case "":
+		if *store == "" {
+			return fmt.Errorf("-out or -store is required")
+		}
+		fileStore, err := openStore(*store)
+		if err != nil {
+			return err
+		}
+		opt.Sink = secrets.Sink(fileStore, "")
+	case "-":
+		opt.Sink = winvps.WriterSink(a.out)
+	default:
+		opt.Sink = winvps.FileSink(*out)
+	}
+	names, err := s.machines(a)
+	if err != nil {
+		return err
+	}
+	results, err := a.client.RotatePasswords(names, opt)
+	if err != nil {
+		return err
+	}
+	if *out == "-" {
+		return results.Err()
+	}
+	return a.printBulk(results)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fozzyhosting/winvps-go-client/secrets"
+)
+
+func init() {
+	registerOffline("secrets list", "list stored credentials: [-store PATH]", secretsList)
+	registerOffline("secrets get", "print stored password: [-store PATH] [-user USER] NAME", secretsGet)
+	register("secrets import", "store passwords of machine additional users: [-store PATH] NAME", secretsImport)
+}
+
+// Returns default path of the encrypted secrets file
+func defaultSecretsPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "winvps", "secrets.enc")
+}
+
+func addStoreFlag(fs *flag.FlagSet) *string {
+	return fs.String("store", defaultSecretsPath(), "encrypted secrets file, passphrase is read from WINVPS_SECRETS_PASSPHRASE env")
+}
+
+func openStore(path string) (*secrets.FileStore, error) {
+	passphrase := os.Getenv("WINVPS_SECRETS_PASSPHRASE")
+	if passphrase == "" {
+		return nil, fmt.Errorf("secrets passphrase is required, use WINVPS_SECRETS_PASSPHRASE env")
+	}
+	return secrets.OpenFile(path, []byte(passphrase))
+}
+
+func secretsList(a *app, args []string) error {
+	fs := newFlagSet(a, "secrets list")
+	path := addStoreFlag(fs)
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	store, err := openStore(*path)
+	if err != nil {
+		return err
+	}
+	keys, err := store.List()
+	if err != nil {
+		return err
+	}
+	return a.print(keys)
+}
+
+func secretsGet(a *app, args []string) error {
+	fs := newFlagSet(a, "secrets get")
+	path := addStoreFlag(fs)
+	user := fs.String("user", secrets.Administrator, "username")
+	args, err := parseArgs(fs, args, "NAME")
+	if err != nil {
+		return err
+	}
+	store, err := openStore(*path)
+	if err != nil {
+		return err
+	}
+	password, err := store.Get(args[0], *user)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(a.out, password)
+	return err
+}
+
+func secretsImport(a *app, args []string) error {
+	fs := newFlagSet(a, "secrets import")
+	path := addStoreFlag(fs)
+	args, err := parseArgs(fs, args, "NAME")
+	if err != nil {
+		return err
+	}
+	store, err := openStore(*path)
+	if err != nil {
+		return err
+	}
+	n, err := secrets.ImportUsers(store, a.client, args[0])
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(a.out, "%d users stored\n", n)
+	return err
+}
@@ -0,0 +1,229 @@
+package winvps
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Windows Update operation result codes reported in UpdateStatus.ResultCode
+const (
+	UpdateResultNotStarted          = 0
+	UpdateResultInProgress          = 1
+	UpdateResultSucceeded           = 2
+	UpdateResultSucceededWithErrors = 3
+	UpdateResultFailed              = 4
+	UpdateResultAborted             = 5
+)
+
+// Layout of UpdateStatus.UpdateTime, the time is in UTC
+const UpdateTimeLayout = "2006-01-02 15:04:05"
+
+var updateResults = map[int]string{
+	UpdateResultNotStarted:          "not started",
+	UpdateResultInProgress:          "in progress",
+	UpdateResultSucceeded:           "succeeded",
+	UpdateResultSucceededWithErrors: "succeeded with errors",
+	UpdateResultFailed:              "failed",
+	UpdateResultAborted:             "aborted",
+}
+
+var hResults = map[uint32]string{
+	0x00000000: "success",
+	0x80070005: "access denied",
+	0x80070070: "not enough disk space",
+	0x800705B4: "operation timed out",
+	0x80072EE2: "connection to update server timed out",
+	0x80072EFD: "cannot connect to update server",
+	0x8024000B: "operation was cancelled",
+	0x80240016: "another installation is in progress",
+	0x80240017: "update is not applicable",
+	0x8024001E: "update service is stopping",
+	0x80240020: "installation requires a logged on user",
+	0x80240022: "all updates failed to install",
+	0x8024402C: "update server name can't be resolved",
+	0x80244022: "update server is unavailable",
+	0x80246007: "update was not downloaded",
+	0x80246008: "download failed",
+	0x800F0922: "update failed to install, system reserved partition may be full",
+	0x8007000E: "not enough memory",
+}
+
+// Returns readable last update result, e.g. "succeeded"
+func (u *UpdateStatus) Result() string {
+	if text, ok := updateResults[u.ResultCode]; ok {
+		return text
+	}
+	return fmt.Sprintf("unknown result %d", u.ResultCode)
+}
+
+// Returns hex HRESULT code of the last update, e.g. "0x80240022"
+func (u *UpdateStatus) HResultCode() string {
+	return fmt.Sprintf("0x%08X", uint32(u.HResult))
+}
+
+// Returns readable description of the last update HRESULT, codes which aren't
+// well-known are described as hex code
+func (u *UpdateStatus) HResultText() string {
+	if text, ok := hResults[uint32(u.HResult)]; ok {
+		return text
+	}
+	return "error " + u.HResultCode()
+}
+
+// Returns parsed time of the last update
+func (u *UpdateStatus) Time() (time.Time, error) {
+	return time.Parse(UpdateTimeLayout, u.UpdateTime)
+}
+
+// Represents update compliance report options
+type ComplianceOptions struct {
+	// Maximum age of the last update, 30 days by default
+	MaxAge time.Duration
+	// Time the age is measured to, current time by default
+	Now time.Time
+}
+
+// Represents update compliance of a single machine
+type MachineCompliance struct {
+	Machine        string    `json:"machine"`
+	TemplateID     string    `json:"template_id"`
+	Result         string    `json:"result"`
+	HResult        string    `json:"h_result"`
+	HResultText    string    `json:"h_result_text"`
+	RebootRequired bool      `json:"reboot_required"`
+	UpdateTime     time.Time `json:"update_time"`
+	Compliant      bool      `json:"compliant"`
+	// Reasons the machine isn't compliant
+	Issues []string `json:"issues,omitempty"`
+}
+
+// Represents update compliance summary of machines installed from the same template
+type ComplianceSummary struct {
+	TemplateID     string `json:"template_id"`
+	Machines       int    `json:"machines"`
+	Compliant      int    `json:"compliant"`
+	Outdated       int    `json:"outdated"`
+	Failed         int    `json:"failed"`
+	RebootRequired int    `json:"reboot_required"`
+	// Machines without update status
+	Unknown int `json:"unknown"`
+}
+
+// Represents update compliance report of all machines. The api doesn't report machine
+// location, so the summary is grouped by template only
+type ComplianceReport struct {
+	Machines  []*MachineCompliance `json:"machines"`
+	Templates []*ComplianceSummary `json:"templates"`
+}
+
+// Returns machines which aren't compliant
+func (r *ComplianceReport) NonCompliant() []*MachineCompliance {
+	var machines []*MachineCompliance
+	for _, m := range r.Machines {
+		if !m.Compliant {
+			machines = append(machines, m)
+		}
+	}
+	return machines
+}
+
+// Returns human-readable report
+func (r *ComplianceReport) String() string {
+	b := new(strings.Builder)
+	nonCompliant := r.NonCompliant()
+	for _, m := range nonCompliant {
+		fmt.Fprintf(b, "%s: %s\n", m.Machine, strings.Join(m.Issues, ", "))
+	}
+	for _, s := range r.Templates {
+		fmt.Fprintf(b, "template %s: %d machines, %d compliant, %d outdated, %d failed, %d reboot required, %d unknown\n",
+			s.TemplateID, s.Machines, s.Compliant, s.Outdated, s.Failed, s.RebootRequired, s.Unknown)
+	}
+	fmt.Fprintf(b, "%d of %d machines not compliant\n", len(nonCompliant), len(r.Machines))
+	return b.String()
+}
+
+// Fetch all machines and report their Windows update compliance
+func (c *Client) Compliance(opt *ComplianceOptions) (*ComplianceReport, error) {
+	machines, err := ListAll(c.GetMachinesFull)
+	if err != nil {
+		return nil, err
+	}
+	return CheckCompliance(machines, opt), nil
+}
+
+// Report Windows update compliance of machines. A machine isn't compliant when its update
+// status is missing, the last update didn't succeed, is older than MaxAge or reboot is pending
+func CheckCompliance(machines []*MachineFull, opt *ComplianceOptions) *ComplianceReport {
+	if opt == nil {
+		opt = &ComplianceOptions{}
+	}
+	maxAge := opt.MaxAge
+	if maxAge == 0 {
+		maxAge = 30 * 24 * time.Hour
+	}
+	now := opt.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	report := &ComplianceReport{}
+	templates := map[string]*ComplianceSummary{}
+	for _, m := range machines {
+		if m.Machine == nil {
+			continue
+		}
+		mc := &MachineCompliance{Machine: m.Name}
+		if m.OS != nil {
+			mc.TemplateID = m.OS.TemplateID
+		}
+		s, ok := templates[mc.TemplateID]
+		if !ok {
+			s = &ComplianceSummary{TemplateID: mc.TemplateID}
+			templates[mc.TemplateID] = s
+			report.Templates = append(report.Templates, s)
+		}
+		s.Machines++
+
+		if m.OS == nil || m.OS.UpdateStatus == nil {
+			mc.Issues = append(mc.Issues, "no update status")
+			s.Unknown++
+			report.Machines = append(report.Machines, mc)
+			continue
+		}
+
+		u := m.OS.UpdateStatus
+		mc.Result, mc.HResult, mc.HResultText = u.Result(), u.HResultCode(), u.HResultText()
+		mc.RebootRequired = u.RebootRequired
+		switch u.ResultCode {
+		case UpdateResultSucceeded, UpdateResultInProgress:
+		default:
+			mc.Issues = append(mc.Issues, fmt.Sprintf("last update %s: %s", mc.Result, mc.HResultText))
+			s.Failed++
+		}
+		if t, err := u.Time(); err != nil {
+			mc.Issues = append(mc.Issues, fmt.Sprintf("unknown update time '%s'", u.UpdateTime))
+			s.Outdated++
+		} else if mc.UpdateTime = t; now.Sub(t) > maxAge {
+			mc.Issues = append(mc.Issues, fmt.Sprintf("last update is older than %s", maxAge))
+			s.Outdated++
+		}
+		if u.RebootRequired {
+			mc.Issues = append(mc.Issues, "reboot required")
+			s.RebootRequired++
+		}
+		report.Machines = append(report.Machines, mc)
+	}
+
+	for _, mc := range report.Machines {
+		mc.Compliant = len(mc.Issues) == 0
+		if mc.Compliant {
+			templates[mc.TemplateID].Compliant++
+		}
+	}
+	sort.Slice(report.Templates, func(i, j int) bool {
+		return report.Templates[i].TemplateID < report.Templates[j].TemplateID
+	})
+	return report
+}
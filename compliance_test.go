@@ -0,0 +1,77 @@
+package winvps
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateStatus(t *testing.T) {
+	u := &UpdateStatus{HResult: -2145124318, ResultCode: UpdateResultFailed, UpdateTime: "2020-10-20 01:02:03"}
+	require.Equal(t, "failed", u.Result())
+	require.Equal(t, "0x80240022", u.HResultCode())
+	require.Equal(t, "all updates failed to install", u.HResultText())
+	tm, err := u.Time()
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2020, 10, 20, 1, 2, 3, 0, time.UTC), tm)
+
+	u = &UpdateStatus{HResult: 0x80241234, ResultCode: 9}
+	require.Equal(t, "unknown result 9", u.Result())
+	require.Equal(t, "error 0x80241234", u.HResultText())
+}
+
+func TestCheckCompliance(t *testing.T) {
+	now := time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC)
+	status := func(code, h int, reboot bool, updated string) *OS {
+		return &OS{TemplateID: "1", UpdateStatus: &UpdateStatus{ResultCode: code, HResult: h, RebootRequired: reboot, UpdateTime: updated}}
+	}
+	machines := []*MachineFull{
+		{Machine: &Machine{Name: "VPS01"}, OS: status(UpdateResultSucceeded, 0, false, "2020-10-20 01:02:03")},
+		{Machine: &Machine{Name: "VPS02"}, OS: status(UpdateResultSucceeded, 0, true, "2020-09-01 01:02:03")},
+		{Machine: &Machine{Name: "VPS03"}, OS: status(UpdateResultFailed, 0x80070070, false, "2020-10-30 00:00:00")},
+		{Machine: &Machine{Name: "VPS04"}, OS: &OS{TemplateID: "2"}},
+	}
+
+	report := CheckCompliance(machines, &ComplianceOptions{Now: now})
+	require.Len(t, report.Machines, 4)
+	require.True(t, report.Machines[0].Compliant)
+	require.Equal(t, []string{"last update is older than 720h0m0s", "reboot required"}, report.Machines[1].Issues)
+	require.Equal(t, []string{"last update failed: not enough disk space"}, report.Machines[2].Issues)
+	require.Equal(t, []string{"no update status"}, report.Machines[3].Issues)
+	require.Len(t, report.NonCompliant(), 3)
+	require.Equal(t, []*ComplianceSummary{
+		{TemplateID: "1", Machines: 3, Compliant: 1, Outdated: 1, Failed: 1, RebootRequired: 1},
+		{TemplateID: "2", Machines: 1, Unknown: 1},
+	}, report.Templates)
+	require.Equal(t, `VPS02: last update is older than 720h0m0s, reboot required
+VPS03: last update failed: not enough disk space
+VPS04: no update status
+template 1: 3 machines, 1 compliant, 1 outdated, 1 failed, 1 reboot required, 0 unknown
+template 2: 1 machines, 0 compliant, 0 outdated, 0 failed, 0 reboot required, 1 unknown
+3 of 4 machines not compliant
+`, report.String())
+}
+
+func TestCompliance(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	mux.HandleFunc(apiVerPath+"machines/full", func(w http.ResponseWriter, r *http.Request) {
+		writeFixture(t, w, "machinesfull.json")
+	})
+
+	report, err := client.Compliance(&ComplianceOptions{MaxAge: 24 * time.Hour, Now: time.Date(2020, 10, 20, 12, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	require.Equal(t, []*MachineCompliance{{
+		Machine:        "VPS0123",
+		TemplateID:     "1",
+		Result:         "in progress",
+		HResult:        "0x00000001",
+		HResultText:    "error 0x00000001",
+		RebootRequired: true,
+		UpdateTime:     time.Date(2020, 10, 20, 1, 2, 3, 0, time.UTC),
+		Issues:         []string{"reboot required"},
+	}}, report.Machines)
+}
@@ -0,0 +1,98 @@
+package winvps
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Synthetic response data of dry-run requests, it satisfies results of all mutating calls:
+// no jobs, successful result, placeholder machine name and IP address
+const dryRunResponse = `{"data":{"name":"dry-run","address":"0.0.0.0","jobs":[],"result":true}}`
+
+// Represents a request recorded instead of being sent in dry-run mode
+type DryRunRequest struct {
+	Operation string `json:"operation"`
+	Method    string `json:"method"`
+	// Request path with query
+	URL string `json:"url"`
+	// Request json body, password fields are redacted
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// Represents requests recorded in dry-run mode
+type dryRun struct {
+	mu       sync.Mutex
+	requests []*DryRunRequest
+}
+
+// Enable dry-run mode. Mutating calls, like CreateMachine, DeleteMachine, SendMachineCommand
+// or CancelJob, are validated and built but not sent, they return synthetic successful result
+// without jobs and the would-be request is recorded, see DryRunRequests(). Read-only calls are sent
+func DryRun(enabled bool) Option {
+	return func(c *Client) error {
+		c.dryRun = nil
+		if enabled {
+			c.dryRun = &dryRun{}
+		}
+		return nil
+	}
+}
+
+// Reports whether dry-run mode is enabled. Callers with local side effects of mutating calls,
+// like storing a new password, should skip them in dry-run mode
+func (c *Client) DryRunEnabled() bool {
+	return c.dryRun != nil
+}
+
+// Returns requests recorded in dry-run mode in order they were made
+func (c *Client) DryRunRequests() []*DryRunRequest {
+	if c.dryRun == nil {
+		return nil
+	}
+	c.dryRun.mu.Lock()
+	defer c.dryRun.mu.Unlock()
+	return append([]*DryRunRequest(nil), c.dryRun.requests...)
+}
+
+// Record mutating requests instead of sending them, read-only requests are passed to next
+func (d *dryRun) wrap(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, []byte, error) {
+		if req.Method == http.MethodGet || req.Method == http.MethodHead {
+			return next(req)
+		}
+
+		r := &DryRunRequest{Operation: OperationFromContext(req.Context()), Method: req.Method, URL: req.URL.RequestURI()}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, nil, err
+			}
+			data, err := io.ReadAll(body)
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(data) > 0 {
+				r.Body = redactJSON(data)
+			}
+		}
+		d.mu.Lock()
+		d.requests = append(d.requests, r)
+		d.mu.Unlock()
+
+		body := []byte(dryRunResponse)
+		resp := &http.Response{
+			Status:     "200 OK",
+			StatusCode: http.StatusOK,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(bytes.NewReader(body)),
+			Request:    req,
+		}
+		return resp, body, nil
+	}
+}
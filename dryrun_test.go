@@ -0,0 +1,77 @@
+package winvps
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+	require.NoError(t, DryRun(true)(client))
+
+	mux.HandleFunc(apiVerPath+"machines", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method, "mutating request must not be sent")
+		writeFixture(t, w, "machines.json")
+	})
+	mux.HandleFunc(apiVerPath+"machines/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("mutating request must not be sent: %s %s", r.Method, r.URL)
+	})
+	mux.HandleFunc(apiVerPath+"jobs/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("mutating request must not be sent: %s %s", r.Method, r.URL)
+	})
+
+	machines, _, err := client.GetMachines()
+	require.NoError(t, err)
+	require.Len(t, machines, 1)
+
+	name, jobs, err := client.CreateMachine(&CreateMachineOptions{ProductID: 1, TemplateID: 2, LocationID: 3, Password: "Secret-123"})
+	require.NoError(t, err)
+	require.Equal(t, "dry-run", name)
+	require.Empty(t, jobs)
+
+	jobs, err = client.UpdateMachine("VPS01", &UpdateMachineOptions{AddCpu: 1})
+	require.NoError(t, err)
+	require.Empty(t, jobs)
+	_, err = client.ReinstallMachine("VPS01", &ReinstallMachineOptions{TemplateID: 2})
+	require.NoError(t, err)
+	_, err = client.DeleteMachine("VPS01")
+	require.NoError(t, err)
+	_, err = client.SendMachineCommand("VPS01", "restart")
+	require.NoError(t, err)
+	address, _, err := client.AddMachineIP("VPS01")
+	require.NoError(t, err)
+	require.Equal(t, "0.0.0.0", address)
+	ok, err := client.ChangeMachinePassword("VPS01", "Secret-123")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, client.CancelJob(5))
+	require.NoError(t, client.WaitJobs(jobs, 0, 0))
+
+	// validation still happens before recording
+	_, err = client.SendMachineCommand("VPS01", "explode")
+	require.Error(t, err)
+	_, _, err = client.CreateMachine(&CreateMachineOptions{})
+	require.Error(t, err)
+
+	requests := client.DryRunRequests()
+	data, err := json.Marshal(requests)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "Secret-123")
+	require.JSONEq(t, `[
+		{"operation":"CreateMachine","method":"POST","url":"/api/v2/machines","body":{"password":"[REDACTED]","product_id":1,"template_id":2,"location_id":3}},
+		{"operation":"UpdateMachine","method":"PUT","url":"/api/v2/machines/VPS01","body":{"add_cpu":1}},
+		{"operation":"ReinstallMachine","method":"POST","url":"/api/v2/machines/VPS01","body":{"template_id":2}},
+		{"operation":"DeleteMachine","method":"DELETE","url":"/api/v2/machines/VPS01"},
+		{"operation":"SendMachineCommand","method":"POST","url":"/api/v2/machines/VPS01/restart"},
+		{"operation":"AddMachineIP","method":"POST","url":"/api/v2/machines/VPS01/add_ip"},
+		{"operation":"ChangeMachinePassword","method":"POST","url":"/api/v2/machines/VPS01/change_password","body":{"password":"[REDACTED]"}},
+		{"operation":"CancelJob","method":"DELETE","url":"/api/v2/jobs/5"}
+	]`, string(data))
+
+	require.NoError(t, DryRun(false)(client))
+	require.Nil(t, client.DryRunRequests())
+}
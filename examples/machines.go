@@ -60,7 +60,7 @@ func updateMachine() {
 	}
 
 	updateOpts := &winvps.UpdateMachineOptions{
-		Password: "newpassword",
+		Password: "N3w-Passw0rd",
 		AddDisk:  10,
 	}
 	machineName := "VPS0123"
@@ -78,7 +78,7 @@ func reinstallMachine() {
 	}
 
 	reinstallOpts := &winvps.ReinstallMachineOptions{
-		Password: "newpassword",
+		Password: "N3w-Passw0rd",
 	}
 	machineName := "VPS0123"
 	jobs, err := winClient.ReinstallMachine(machineName, reinstallOpts)
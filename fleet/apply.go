@@ -0,0 +1,79 @@
+package fleet
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fozzyhosting/winvps-go-client"
+)
+
+// Represents apply options
+type ApplyOptions struct {
+	// Jobs poll interval, 5s by default
+	Interval time.Duration
+	// Maximum wait for jobs of a single change, 0 means wait forever
+	Timeout time.Duration
+	// Called after each applied change
+	Progress func(r *Result)
+}
+
+// Represents result of a single applied change
+type Result struct {
+	Change *Change `json:"change"`
+	// Machine name, assigned by api for created machines
+	Machine string        `json:"machine"`
+	Jobs    []*winvps.Job `json:"jobs"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// Apply plan changes one by one waiting for resulting jobs. Applying stops on the first
+// failed change, results of all attempted changes are returned along with the error
+func Apply(c *winvps.Client, plan *Plan, opt *ApplyOptions) ([]*Result, error) {
+	if opt == nil {
+		opt = &ApplyOptions{}
+	}
+	interval := opt.Interval
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+
+	var results []*Result
+	for _, change := range plan.Changes {
+		r := &Result{Change: change, Machine: change.Machine}
+		results = append(results, r)
+
+		err := applyChange(c, change, r)
+		if err == nil {
+			err = c.WaitJobs(r.Jobs, interval, opt.Timeout)
+		}
+		if err != nil {
+			r.Error = err.Error()
+		}
+		if opt.Progress != nil {
+			opt.Progress(r)
+		}
+		if err != nil {
+			return results, fmt.Errorf("%s %s: %v", change.Action, change.Notes, err)
+		}
+	}
+	return results, nil
+}
+
+func applyChange(c *winvps.Client, change *Change, r *Result) error {
+	var err error
+	switch change.Action {
+	case ActionCreate:
+		r.Machine, r.Jobs, err = c.CreateMachine(change.Create)
+	case ActionReinstall:
+		r.Jobs, err = c.ReinstallMachine(change.Machine, change.Reinstall)
+	case ActionUpdate:
+		r.Jobs, err = c.UpdateMachine(change.Machine, change.Update)
+	case ActionStart, ActionStop:
+		r.Jobs, err = c.SendMachineCommand(change.Machine, change.Action)
+	case ActionDelete:
+		r.Jobs, err = c.DeleteMachine(change.Machine)
+	default:
+		err = fmt.Errorf("unknown action '%s'", change.Action)
+	}
+	return err
+}
@@ -0,0 +1,54 @@
+package fleet
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/fozzyhosting/winvps-go-client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/api/v2/machines", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		require.JSONEq(t, `{"description":"web-01","product_id":1,"template_id":2,"location_id":1}`, string(body))
+		fmt.Fprint(w, `{"data":{"name":"VPS01","jobs":[{"id":1,"status":"Pending"}]}}`)
+	})
+	mux.HandleFunc("/api/v2/machines/VPS02/stop", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"jobs":[{"id":2,"status":"Pending"}]}}`)
+	})
+	mux.HandleFunc("/api/v2/jobs/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"id":1,"status":"Complete"}}`)
+	})
+	mux.HandleFunc("/api/v2/jobs/2", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"id":2,"status":"Failed"}}`)
+	})
+
+	client, err := winvps.NewClient("secret", winvps.BaseURL(server.URL))
+	require.NoError(t, err)
+
+	plan := &Plan{Changes: []*Change{
+		{Action: ActionCreate, Notes: "web-01", Create: &winvps.CreateMachineOptions{Description: "web-01", ProductID: 1, TemplateID: 2, LocationID: 1}},
+		{Action: ActionStop, Machine: "VPS02", Notes: "web-02"},
+		{Action: ActionDelete, Machine: "VPS03", Notes: "web-03"},
+	}}
+
+	var progress []string
+	results, err := Apply(client, plan, &ApplyOptions{Interval: time.Millisecond, Progress: func(r *Result) {
+		progress = append(progress, r.Change.Action+" "+r.Machine)
+	}})
+	require.EqualError(t, err, "stop web-02: job 2 failed")
+	require.Len(t, results, 2)
+	require.Equal(t, "VPS01", results[0].Machine)
+	require.Equal(t, []*winvps.Job{{ID: 1, Status: winvps.JobStatusComplete}}, results[0].Jobs)
+	require.Equal(t, "job 2 failed", results[1].Error)
+	require.Equal(t, []string{"create VPS01", "stop VPS02"}, progress)
+}
@@ -0,0 +1,170 @@
+package fleet
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fozzyhosting/winvps-go-client"
+	"gopkg.in/yaml.v3"
+)
+
+// Represents desired state of a single machine, zero fields are not checked
+type Desired struct {
+	Limits     *winvps.Limits `json:"limits,omitempty"`
+	TemplateID int            `json:"template_id,omitempty"`
+	BrandID    int            `json:"brand_id,omitempty"`
+	// Machine status, e.g. Running or Stopped, compared case-insensitively
+	Status string `json:"status,omitempty"`
+}
+
+// Represents drift of a single machine
+type MachineDrift struct {
+	Machine string `json:"machine"`
+	// Machine doesn't exist
+	Missing bool         `json:"missing,omitempty"`
+	Fields  []*FieldDiff `json:"fields,omitempty"`
+}
+
+// Represents drift report of all checked machines, only drifted machines are listed
+type DriftReport struct {
+	Checked  int             `json:"checked"`
+	Machines []*MachineDrift `json:"machines"`
+}
+
+// Reports whether any machine drifted from desired state
+func (r *DriftReport) HasDrift() bool {
+	return len(r.Machines) > 0
+}
+
+// Returns human-readable report
+func (r *DriftReport) String() string {
+	b := new(strings.Builder)
+	for _, m := range r.Machines {
+		if m.Missing {
+			fmt.Fprintf(b, "%s: missing\n", m.Machine)
+			continue
+		}
+		fmt.Fprintf(b, "%s:\n", m.Machine)
+		for _, d := range m.Fields {
+			fmt.Fprintf(b, "    %s: %s, desired %s\n", d.Field, d.Current, d.Desired)
+		}
+	}
+	fmt.Fprintf(b, "%d of %d machines drifted\n", len(r.Machines), r.Checked)
+	return b.String()
+}
+
+// Read desired state keyed by machine name from yaml or json file, e.g.
+//
+//	VPS0123:
+//	  template_id: 1
+//	  status: Running
+//	  limits:
+//	    cpu_cores: 2
+func LoadDesired(path string) (map[string]*Desired, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// decode through json, so that json tags of winvps.Limits are used
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("unable to parse desired state %s: %v", path, err)
+	}
+	data, err = json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse desired state %s: %v", path, err)
+	}
+	desired := map[string]*Desired{}
+	if err := json.Unmarshal(data, &desired); err != nil {
+		return nil, fmt.Errorf("unable to parse desired state %s: %v", path, err)
+	}
+	return desired, nil
+}
+
+// Fetch all machines and compare them with desired state
+func CheckDrift(c *winvps.Client, desired map[string]*Desired) (*DriftReport, error) {
+	machines, err := winvps.ListAll(c.GetMachinesFull)
+	if err != nil {
+		return nil, err
+	}
+	return Drift(desired, machines), nil
+}
+
+// Compare machines with desired state keyed by machine name, machines without
+// desired state are not checked
+func Drift(desired map[string]*Desired, machines []*winvps.MachineFull) *DriftReport {
+	byName := map[string]*winvps.MachineFull{}
+	for _, m := range machines {
+		if m.Machine != nil {
+			byName[m.Name] = m
+		}
+	}
+	names := make([]string, 0, len(desired))
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	r := &DriftReport{Checked: len(names), Machines: []*MachineDrift{}}
+	for _, name := range names {
+		m, ok := byName[name]
+		if !ok {
+			r.Machines = append(r.Machines, &MachineDrift{Machine: name, Missing: true})
+			continue
+		}
+		if fields := machineDrift(desired[name], m); len(fields) > 0 {
+			r.Machines = append(r.Machines, &MachineDrift{Machine: name, Fields: fields})
+		}
+	}
+	return r
+}
+
+func machineDrift(d *Desired, m *winvps.MachineFull) []*FieldDiff {
+	if d == nil {
+		return nil
+	}
+	var fields []*FieldDiff
+	if d.Status != "" && !strings.EqualFold(d.Status, m.Status) {
+		fields = append(fields, diff("status", m.Status, d.Status))
+	}
+
+	mOS := m.OS
+	if mOS == nil {
+		mOS = &winvps.OS{}
+	}
+	if d.TemplateID != 0 && mOS.TemplateID != strconv.Itoa(d.TemplateID) {
+		fields = append(fields, diff("os.template_id", mOS.TemplateID, strconv.Itoa(d.TemplateID)))
+	}
+	if d.BrandID != 0 && mOS.BrandID != d.BrandID {
+		fields = append(fields, diff("os.brand_id", strconv.Itoa(mOS.BrandID), strconv.Itoa(d.BrandID)))
+	}
+
+	if d.Limits == nil {
+		return fields
+	}
+	config := m.Config
+	if config == nil {
+		config = &winvps.Limits{}
+	}
+	for _, f := range []struct {
+		name             string
+		current, desired int
+	}{
+		{"config.cpu_percent", config.CpuPercent, d.Limits.CpuPercent},
+		{"config.cpu_cores", config.CpuCores, d.Limits.CpuCores},
+		{"config.ram_min", config.RamMin, d.Limits.RamMin},
+		{"config.ram_max", config.RamMax, d.Limits.RamMax},
+		{"config.disk_size", config.DiskSize, d.Limits.DiskSize},
+		{"config.bandwidth", config.Bandwidth, d.Limits.Bandwidth},
+		{"config.traffic", config.Traffic, d.Limits.Traffic},
+	} {
+		if f.desired != 0 && f.current != f.desired {
+			fields = append(fields, diff(f.name, strconv.Itoa(f.current), strconv.Itoa(f.desired)))
+		}
+	}
+	return fields
+}
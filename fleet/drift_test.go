@@ -0,0 +1,44 @@
+package fleet
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/fozzyhosting/winvps-go-client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrift(t *testing.T) {
+	desired, err := LoadDesired("testdata/desired.yaml")
+	require.NoError(t, err)
+	require.Equal(t, &Desired{TemplateID: 2, Status: "running", Limits: &winvps.Limits{CpuCores: 2, RamMax: 2048}}, desired["VPS01"])
+
+	machines := []*winvps.MachineFull{
+		machine("VPS01", "", "Running", "1", 1, &winvps.Limits{CpuCores: 1, RamMax: 2048, DiskSize: 30}),
+		machine("VPS02", "", "Stopped", "1", 1, nil),
+		machine("VPS03", "", "Stopped", "1", 2, nil),
+	}
+
+	r := Drift(desired, machines)
+	require.True(t, r.HasDrift())
+	require.Equal(t, &DriftReport{Checked: 3, Machines: []*MachineDrift{
+		{Machine: "VPS01", Fields: []*FieldDiff{{"os.template_id", "1", "2"}, {"config.cpu_cores", "1", "2"}}},
+		{Machine: "VPS09", Missing: true},
+	}}, r)
+
+	want := `VPS01:
+    os.template_id: 1, desired 2
+    config.cpu_cores: 1, desired 2
+VPS09: missing
+2 of 3 machines drifted
+`
+	require.Equal(t, want, r.String())
+
+	data, err := json.Marshal(r)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `{"machine":"VPS09","missing":true}`)
+
+	r = Drift(map[string]*Desired{"VPS02": {BrandID: 1}}, machines)
+	require.False(t, r.HasDrift())
+	require.Equal(t, "0 of 1 machines drifted\n", r.String())
+}
@@ -0,0 +1,260 @@
+package fleet
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fozzyhosting/winvps-go-client"
+)
+
+// Plan actions in the order they are applied
+const (
+	ActionCreate    = "create"
+	ActionReinstall = "reinstall"
+	ActionUpdate    = "update"
+	ActionStart     = "start"
+	ActionStop      = "stop"
+	ActionDelete    = "delete"
+)
+
+var actionOrder = map[string]int{
+	ActionCreate:    0,
+	ActionReinstall: 1,
+	ActionUpdate:    2,
+	ActionStart:     3,
+	ActionStop:      4,
+	ActionDelete:    5,
+}
+
+// Represents a difference of a single field
+type FieldDiff struct {
+	Field   string `json:"field"`
+	Current string `json:"current"`
+	Desired string `json:"desired"`
+}
+
+// Represents a single planned change
+type Change struct {
+	Action string `json:"action"`
+	// Actual machine name, empty for create
+	Machine string `json:"machine,omitempty"`
+	// Machine notes from the spec
+	Notes     string                          `json:"notes"`
+	Diff      []*FieldDiff                    `json:"diff,omitempty"`
+	Create    *winvps.CreateMachineOptions    `json:"create,omitempty"`
+	Update    *winvps.UpdateMachineOptions    `json:"update,omitempty"`
+	Reinstall *winvps.ReinstallMachineOptions `json:"reinstall,omitempty"`
+}
+
+// Represents a list of changes required to bring the fleet to the spec
+type Plan struct {
+	Changes []*Change `json:"changes"`
+}
+
+// Reports whether the fleet already matches the spec
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// Returns human-readable plan
+func (p *Plan) String() string {
+	if p.Empty() {
+		return "No changes, fleet matches the spec.\n"
+	}
+	signs := map[string]string{
+		ActionCreate:    "+",
+		ActionReinstall: "!",
+		ActionUpdate:    "~",
+		ActionStart:     ">",
+		ActionStop:      ">",
+		ActionDelete:    "-",
+	}
+	b := new(strings.Builder)
+	counts := map[string]int{}
+	for _, c := range p.Changes {
+		counts[c.Action]++
+		name := c.Notes
+		if c.Machine != "" {
+			name = fmt.Sprintf("%s (%s)", c.Machine, c.Notes)
+		}
+		fmt.Fprintf(b, "%s %s %s\n", signs[c.Action], c.Action, name)
+		for _, d := range c.Diff {
+			fmt.Fprintf(b, "    %s: %s -> %s\n", d.Field, d.Current, d.Desired)
+		}
+	}
+	fmt.Fprintf(b, "\nPlan: %d to create, %d to reinstall, %d to update, %d to start, %d to stop, %d to delete.\n",
+		counts[ActionCreate], counts[ActionReinstall], counts[ActionUpdate],
+		counts[ActionStart], counts[ActionStop], counts[ActionDelete])
+	return b.String()
+}
+
+// Fetch machines and products and compute the plan
+func NewPlan(c *winvps.Client, spec *Spec) (*Plan, error) {
+	machines, err := winvps.ListAll(c.GetMachinesFull)
+	if err != nil {
+		return nil, err
+	}
+	products, err := winvps.ListAll(c.GetProducts)
+	if err != nil {
+		return nil, err
+	}
+	return Compute(spec, machines, products)
+}
+
+// Compute the plan for machines, products are used to find expected machine config.
+// Expected config is product limits increased by additional resources of the group
+func Compute(spec *Spec, machines []*winvps.MachineFull, products []*winvps.Product) (*Plan, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	byNotes := map[string]*winvps.MachineFull{}
+	for _, m := range machines {
+		if m.Machine != nil && m.Notes != "" {
+			byNotes[m.Notes] = m
+		}
+	}
+	limits := map[int]*winvps.Limits{}
+	for _, p := range products {
+		limits[p.ID] = p.Limits
+	}
+
+	plan := &Plan{}
+	desired := map[string]bool{}
+	for _, g := range spec.Groups {
+		product, ok := limits[g.ProductID]
+		if !ok || product == nil {
+			return nil, fmt.Errorf("group %s: product %d not found", g.Name, g.ProductID)
+		}
+		for _, notes := range g.Names() {
+			desired[notes] = true
+			m, ok := byNotes[notes]
+			if !ok {
+				plan.Changes = append(plan.Changes, createChange(g, notes))
+				continue
+			}
+			plan.Changes = append(plan.Changes, machineChanges(g, notes, m, product)...)
+		}
+	}
+
+	if spec.Prune {
+		for _, m := range machines {
+			if m.Machine == nil || desired[m.Notes] {
+				continue
+			}
+			for _, g := range spec.Groups {
+				if g.Matches(m.Notes) {
+					plan.Changes = append(plan.Changes, &Change{Action: ActionDelete, Machine: m.Name, Notes: m.Notes})
+					break
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(plan.Changes, func(i, j int) bool {
+		return actionOrder[plan.Changes[i].Action] < actionOrder[plan.Changes[j].Action]
+	})
+	return plan, nil
+}
+
+func createChange(g *Group, notes string) *Change {
+	opt := &winvps.CreateMachineOptions{
+		Description: notes,
+		ProductID:   g.ProductID,
+		TemplateID:  g.TemplateID,
+		BrandID:     g.BrandID,
+		LocationID:  g.LocationID,
+		DiskType:    g.DiskType,
+		AddCpu:      g.AddCpu,
+		AddRam:      g.AddRam,
+		AddDisk:     g.AddDisk,
+		AddBand:     g.AddBand,
+		AutoStart:   boolInt(g.AutoStart || g.State == StateRunning),
+		AddIPv6:     boolInt(g.IPv6),
+	}
+	return &Change{Action: ActionCreate, Notes: notes, Create: opt}
+}
+
+// Returns reinstall, update and power changes of existing machine
+func machineChanges(g *Group, notes string, m *winvps.MachineFull, product *winvps.Limits) []*Change {
+	var changes []*Change
+
+	reinstall := &Change{Action: ActionReinstall, Machine: m.Name, Notes: notes}
+	if m.OS == nil || m.OS.TemplateID != strconv.Itoa(g.TemplateID) {
+		current := ""
+		if m.OS != nil {
+			current = m.OS.TemplateID
+		}
+		reinstall.Diff = append(reinstall.Diff, diff("os.template_id", current, strconv.Itoa(g.TemplateID)))
+	}
+	if g.BrandID != 0 && (m.OS == nil || m.OS.BrandID != g.BrandID) {
+		current := 0
+		if m.OS != nil {
+			current = m.OS.BrandID
+		}
+		reinstall.Diff = append(reinstall.Diff, diff("os.brand_id", strconv.Itoa(current), strconv.Itoa(g.BrandID)))
+	}
+	reinstalled := len(reinstall.Diff) > 0
+	if reinstalled {
+		reinstall.Reinstall = &winvps.ReinstallMachineOptions{
+			TemplateID: g.TemplateID,
+			BrandID:    g.BrandID,
+			AutoStart:  boolInt(g.State == StateRunning || (g.State == "" && m.Status == winvps.MachineStatusRunning)),
+		}
+		changes = append(changes, reinstall)
+	}
+
+	update := &Change{Action: ActionUpdate, Machine: m.Name, Notes: notes}
+	current := m.Config
+	if current == nil {
+		current = &winvps.Limits{}
+	}
+	for _, f := range []struct {
+		name             string
+		current, desired int
+	}{
+		{"config.cpu_cores", current.CpuCores, product.CpuCores + g.AddCpu},
+		{"config.ram_max", current.RamMax, product.RamMax + g.AddRam},
+		{"config.disk_size", current.DiskSize, product.DiskSize + g.AddDisk},
+		{"config.bandwidth", current.Bandwidth, product.Bandwidth + g.AddBand},
+	} {
+		if f.current != f.desired {
+			update.Diff = append(update.Diff, diff(f.name, strconv.Itoa(f.current), strconv.Itoa(f.desired)))
+		}
+	}
+	if len(update.Diff) > 0 {
+		update.Update = &winvps.UpdateMachineOptions{
+			ProductID: g.ProductID,
+			AddCpu:    g.AddCpu,
+			AddRam:    g.AddRam,
+			AddDisk:   g.AddDisk,
+			AddBand:   g.AddBand,
+		}
+		changes = append(changes, update)
+	}
+
+	// reinstall starts machine according to AutoStart
+	if g.State != "" && !reinstalled {
+		switch {
+		case g.State == StateRunning && m.Status != winvps.MachineStatusRunning:
+			changes = append(changes, &Change{Action: ActionStart, Machine: m.Name, Notes: notes,
+				Diff: []*FieldDiff{diff("status", m.Status, winvps.MachineStatusRunning)}})
+		case g.State == StateStopped && m.Status != winvps.MachineStatusStopped:
+			changes = append(changes, &Change{Action: ActionStop, Machine: m.Name, Notes: notes,
+				Diff: []*FieldDiff{diff("status", m.Status, winvps.MachineStatusStopped)}})
+		}
+	}
+	return changes
+}
+
+func diff(field, current, desired string) *FieldDiff {
+	return &FieldDiff{Field: field, Current: current, Desired: desired}
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
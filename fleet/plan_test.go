@@ -0,0 +1,87 @@
+package fleet
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/fozzyhosting/winvps-go-client"
+	"github.com/stretchr/testify/require"
+)
+
+var products = []*winvps.Product{{ID: 1, Name: "start", Limits: &winvps.Limits{CpuCores: 1, RamMax: 1024, DiskSize: 30, Bandwidth: 10}}}
+
+func machine(name, notes, status, template string, brand int, config *winvps.Limits) *winvps.MachineFull {
+	return &winvps.MachineFull{
+		Machine: &winvps.Machine{Name: name, Status: status, Notes: notes},
+		OS:      &winvps.OS{TemplateID: template, BrandID: brand},
+		Config:  config,
+	}
+}
+
+func TestCompute(t *testing.T) {
+	spec, err := LoadSpec("testdata/spec.yaml")
+	require.NoError(t, err)
+
+	machines := []*winvps.MachineFull{
+		// matches the spec
+		machine("VPS01", "web-01", "Running", "2", 0, &winvps.Limits{CpuCores: 1, RamMax: 2048, DiskSize: 30, Bandwidth: 10}),
+		// wrong template and brand, stopped machine is started by reinstall
+		machine("VPS03", "db", "Stopped", "2", 2, &winvps.Limits{CpuCores: 1, RamMax: 1024, DiskSize: 30, Bandwidth: 10}),
+		// exceeds the group count
+		machine("VPS04", "web-03", "Running", "2", 0, &winvps.Limits{}),
+		// unmanaged
+		machine("VPS05", "other", "Running", "2", 0, &winvps.Limits{}),
+	}
+
+	plan, err := Compute(spec, machines, products)
+	require.NoError(t, err)
+	require.Equal(t, []*Change{
+		{Action: ActionCreate, Notes: "web-02", Create: &winvps.CreateMachineOptions{
+			Description: "web-02", ProductID: 1, TemplateID: 2, LocationID: 1, AddRam: 1024, AutoStart: 1}},
+		{Action: ActionReinstall, Machine: "VPS03", Notes: "db",
+			Diff:      []*FieldDiff{{"os.template_id", "2", "3"}, {"os.brand_id", "2", "1"}},
+			Reinstall: &winvps.ReinstallMachineOptions{TemplateID: 3, BrandID: 1}},
+		{Action: ActionDelete, Machine: "VPS04", Notes: "web-03"},
+	}, plan.Changes)
+
+	want := `+ create web-02
+! reinstall VPS03 (db)
+    os.template_id: 2 -> 3
+    os.brand_id: 2 -> 1
+- delete VPS04 (web-03)
+
+Plan: 1 to create, 1 to reinstall, 0 to update, 0 to start, 0 to stop, 1 to delete.
+`
+	require.Equal(t, want, plan.String())
+
+	data, err := json.Marshal(plan)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `{"action":"delete","machine":"VPS04","notes":"web-03"}`)
+}
+
+func TestComputeUpdateAndPower(t *testing.T) {
+	spec := &Spec{Groups: []*Group{{Name: "app", ProductID: 1, TemplateID: 2, LocationID: 1, AddCpu: 1, State: StateStopped}}}
+	machines := []*winvps.MachineFull{
+		machine("VPS01", "app", "Running", "2", 0, &winvps.Limits{CpuCores: 1, RamMax: 1024, DiskSize: 30, Bandwidth: 10}),
+	}
+
+	plan, err := Compute(spec, machines, products)
+	require.NoError(t, err)
+	require.Equal(t, []*Change{
+		{Action: ActionUpdate, Machine: "VPS01", Notes: "app",
+			Diff:   []*FieldDiff{{"config.cpu_cores", "1", "2"}},
+			Update: &winvps.UpdateMachineOptions{ProductID: 1, AddCpu: 1}},
+		{Action: ActionStop, Machine: "VPS01", Notes: "app", Diff: []*FieldDiff{{"status", "Running", "Stopped"}}},
+	}, plan.Changes)
+
+	machines[0].Config.CpuCores = 2
+	machines[0].Status = "Stopped"
+	plan, err = Compute(spec, machines, products)
+	require.NoError(t, err)
+	require.True(t, plan.Empty())
+	require.Equal(t, "No changes, fleet matches the spec.\n", plan.String())
+
+	spec.Groups[0].ProductID = 2
+	_, err = Compute(spec, machines, products)
+	require.EqualError(t, err, "group app: product 2 not found")
+}
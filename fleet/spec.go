@@ -0,0 +1,116 @@
+// Package fleet manages winvps machines from a declarative specification.
+//
+// The api assigns machine names on creation, so machines are identified by their notes,
+// which hold the description passed to CreateMachine. A group of the spec describes
+// one or more machines with a notes pattern, e.g. "web-%02d" with count 3 describes
+// machines with notes web-01, web-02 and web-03.
+package fleet
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Desired power states
+const (
+	StateRunning = "running"
+	StateStopped = "stopped"
+)
+
+// Represents a fleet specification
+type Spec struct {
+	Groups []*Group `yaml:"groups" json:"groups"`
+	// Delete machines which match a group pattern but exceed its count
+	Prune bool `yaml:"prune" json:"prune"`
+}
+
+// Represents a group of identical machines
+type Group struct {
+	// Notes pattern, may contain a single integer verb, e.g. "web-%02d"
+	Name       string `yaml:"name" json:"name"`
+	Count      int    `yaml:"count" json:"count"`
+	ProductID  int    `yaml:"product_id" json:"product_id"`
+	TemplateID int    `yaml:"template_id" json:"template_id"`
+	BrandID    int    `yaml:"brand_id" json:"brand_id"`
+	LocationID int    `yaml:"location_id" json:"location_id"`
+	DiskType   string `yaml:"disk_type" json:"disk_type"`
+	AddCpu     int    `yaml:"add_cpu" json:"add_cpu"`
+	AddRam     int    `yaml:"add_ram" json:"add_ram"`
+	AddDisk    int    `yaml:"add_disk" json:"add_disk"`
+	AddBand    int    `yaml:"add_band" json:"add_band"`
+	IPv6       bool   `yaml:"ipv6" json:"ipv6"`
+	AutoStart  bool   `yaml:"auto_start" json:"auto_start"`
+	// Desired power state, running or stopped, not managed if empty
+	State string `yaml:"state" json:"state"`
+}
+
+// Read spec from yaml or json file and validate it
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Spec{}
+	if err := yaml.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("unable to parse spec %s: %v", path, err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Validate spec for required fields and duplicated machines
+func (s *Spec) Validate() error {
+	seen := map[string]bool{}
+	for i, g := range s.Groups {
+		if g.Name == "" {
+			return fmt.Errorf("group %d: missing required option name", i)
+		}
+		if g.ProductID == 0 || g.TemplateID == 0 || g.LocationID == 0 {
+			return fmt.Errorf("group %s: product_id, template_id and location_id are required", g.Name)
+		}
+		if g.State != "" && g.State != StateRunning && g.State != StateStopped {
+			return fmt.Errorf("group %s: allowed state '%s' or '%s' but '%s' passed", g.Name, StateRunning, StateStopped, g.State)
+		}
+		if g.Count > 1 && !strings.Contains(g.Name, "%") {
+			return fmt.Errorf("group %s: name must contain a number verb, e.g. %%02d, when count is greater than 1", g.Name)
+		}
+		for _, name := range g.Names() {
+			if seen[name] {
+				return fmt.Errorf("group %s: machine %s is described more than once", g.Name, name)
+			}
+			seen[name] = true
+		}
+	}
+	return nil
+}
+
+// Returns notes of all machines of the group
+func (g *Group) Names() []string {
+	if !strings.Contains(g.Name, "%") {
+		return []string{g.Name}
+	}
+	count := g.Count
+	if count == 0 {
+		count = 1
+	}
+	names := make([]string, count)
+	for i := range names {
+		names[i] = fmt.Sprintf(g.Name, i+1)
+	}
+	return names
+}
+
+// Reports whether notes match the group pattern, used to find machines exceeding the count
+func (g *Group) Matches(notes string) bool {
+	if !strings.Contains(g.Name, "%") {
+		return notes == g.Name
+	}
+	var n int
+	_, err := fmt.Sscanf(notes, g.Name, &n)
+	return err == nil && fmt.Sprintf(g.Name, n) == notes
+}
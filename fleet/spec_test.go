@@ -0,0 +1,49 @@
+package fleet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSpec(t *testing.T) {
+	s, err := LoadSpec("testdata/spec.yaml")
+	require.NoError(t, err)
+	require.True(t, s.Prune)
+	require.Len(t, s.Groups, 2)
+	require.Equal(t, &Group{Name: "web-%02d", Count: 2, ProductID: 1, TemplateID: 2, LocationID: 1, AddRam: 1024, State: StateRunning}, s.Groups[0])
+	require.Equal(t, []string{"web-01", "web-02"}, s.Groups[0].Names())
+	require.Equal(t, []string{"db"}, s.Groups[1].Names())
+}
+
+func TestGroupMatches(t *testing.T) {
+	g := &Group{Name: "web-%02d", Count: 2}
+	require.True(t, g.Matches("web-01"))
+	require.True(t, g.Matches("web-13"))
+	require.False(t, g.Matches("web-1"))
+	require.False(t, g.Matches("web-01-old"))
+	require.False(t, g.Matches("db"))
+
+	g = &Group{Name: "db"}
+	require.True(t, g.Matches("db"))
+	require.False(t, g.Matches("db2"))
+}
+
+func TestValidateSpec(t *testing.T) {
+	tests := []struct {
+		spec *Spec
+		err  string
+	}{
+		{&Spec{Groups: []*Group{{ProductID: 1, TemplateID: 1, LocationID: 1}}}, "group 0: missing required option name"},
+		{&Spec{Groups: []*Group{{Name: "db", ProductID: 1}}}, "group db: product_id, template_id and location_id are required"},
+		{&Spec{Groups: []*Group{{Name: "db", ProductID: 1, TemplateID: 1, LocationID: 1, State: "paused"}}},
+			"group db: allowed state 'running' or 'stopped' but 'paused' passed"},
+		{&Spec{Groups: []*Group{{Name: "web", Count: 2, ProductID: 1, TemplateID: 1, LocationID: 1}}},
+			"group web: name must contain a number verb, e.g. %02d, when count is greater than 1"},
+		{&Spec{Groups: []*Group{{Name: "db", ProductID: 1, TemplateID: 1, LocationID: 1}, {Name: "db", ProductID: 1, TemplateID: 1, LocationID: 1}}},
+			"group db: machine db is described more than once"},
+	}
+	for _, tt := range tests {
+		require.EqualError(t, tt.spec.Validate(), tt.err)
+	}
+}
@@ -0,0 +1,162 @@
+package winvps
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Operations guarded by default
+var DestructiveOperations = []string{"DeleteMachine", "ReinstallMachine"}
+
+// Represents guard rails of destructive operations
+type GuardOptions struct {
+	// Guarded operations, DestructiveOperations by default
+	Operations []string
+	// Machine name glob patterns, e.g. "PROD*", guarded operations are denied on
+	Deny []string
+	// Machine name glob patterns guarded operations are allowed on, all machines if empty
+	Allow []string
+	// Machines with notes containing the tag are protected: guarded operations are denied
+	// on them and bulk operations skip them
+	ProtectedTag string
+	// Called before each guarded operation passed the other checks, the operation is denied
+	// unless it returns true. Calls are serialized, it isn't called in dry-run mode
+	Confirm func(operation, machine string) (bool, error)
+	// Maximum number of guarded operations per client, 0 means unlimited. Operations made
+	// in dry-run mode aren't counted
+	MaxCalls int
+}
+
+// Represents guard rails state
+type guard struct {
+	opt *GuardOptions
+	ops map[string]bool
+
+	mu    sync.Mutex
+	calls int
+}
+
+// Enable guard rails of destructive operations, like DeleteMachine and ReinstallMachine.
+// Denied operations aren't sent and return an error. Guarded operations on protected machines
+// fetch the machine to check its notes
+func Guard(opt *GuardOptions) Option {
+	return func(c *Client) error {
+		if opt == nil {
+			c.guard = nil
+			return nil
+		}
+		for _, pattern := range append(append([]string{}, opt.Deny...), opt.Allow...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid machine name pattern %q: %v", pattern, err)
+			}
+		}
+		ops := opt.Operations
+		if len(ops) == 0 {
+			ops = DestructiveOperations
+		}
+		g := &guard{opt: opt, ops: map[string]bool{}}
+		for _, op := range ops {
+			g.ops[op] = true
+		}
+		c.guard = g
+		return nil
+	}
+}
+
+// Deny guarded round-trips failing the checks
+func (g *guard) wrap(c *Client, next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, []byte, error) {
+		if req.Method == http.MethodGet || req.Method == http.MethodHead {
+			return next(req)
+		}
+		op := OperationFromContext(req.Context())
+		if !g.ops[op] {
+			return next(req)
+		}
+		if err := g.check(c, op, machineFromPath(req.URL.Path)); err != nil {
+			return nil, nil, err
+		}
+		return next(req)
+	}
+}
+
+// Returns error if the operation on machine is denied
+func (g *guard) check(c *Client, op, machine string) error {
+	if err := g.checkName(machine); err != nil {
+		return fmt.Errorf("%s of %s denied: %v", op, machine, err)
+	}
+	if g.opt.ProtectedTag != "" && machine != "" {
+		m, err := c.GetMachine(machine)
+		if err != nil {
+			return fmt.Errorf("%s of %s denied: unable to check protection: %v", op, machine, err)
+		}
+		if g.protected(m.Machine) {
+			return fmt.Errorf("%s of %s denied: machine is protected", op, machine)
+		}
+	}
+
+	// nothing is sent in dry-run mode, so neither confirmation nor budget is needed
+	if c.DryRunEnabled() {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.opt.MaxCalls > 0 && g.calls >= g.opt.MaxCalls {
+		return fmt.Errorf("%s of %s denied: limit of %d destructive calls reached", op, machine, g.opt.MaxCalls)
+	}
+	if g.opt.Confirm != nil {
+		ok, err := g.opt.Confirm(op, machine)
+		if err != nil {
+			return fmt.Errorf("%s of %s denied: %v", op, machine, err)
+		}
+		if !ok {
+			return fmt.Errorf("%s of %s denied: not confirmed", op, machine)
+		}
+	}
+	g.calls++
+	return nil
+}
+
+// Returns error if machine name matches deny patterns or doesn't match allow patterns
+func (g *guard) checkName(name string) error {
+	for _, pattern := range g.opt.Deny {
+		if ok, _ := path.Match(pattern, name); ok {
+			return fmt.Errorf("matches deny pattern %q", pattern)
+		}
+	}
+	if len(g.opt.Allow) == 0 {
+		return nil
+	}
+	for _, pattern := range g.opt.Allow {
+		if ok, _ := path.Match(pattern, name); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("doesn't match allow patterns")
+}
+
+// Reports whether machine notes contain the protected tag
+func (g *guard) protected(m *Machine) bool {
+	return g.opt.ProtectedTag != "" && m != nil && strings.Contains(m.Notes, g.opt.ProtectedTag)
+}
+
+// Returns names of protected machines, nil if guard rails or protected tag aren't set
+func (c *Client) ProtectedMachines() (map[string]bool, error) {
+	if c.guard == nil || c.guard.opt.ProtectedTag == "" {
+		return nil, nil
+	}
+	machines, err := ListAll(c.GetMachines)
+	if err != nil {
+		return nil, err
+	}
+	protected := map[string]bool{}
+	for _, m := range machines {
+		if c.guard.protected(m) {
+			protected[m.Name] = true
+		}
+	}
+	return protected, nil
+}
@@ -0,0 +1,147 @@
+package winvps
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuard(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	deleted := []string{}
+	mux.HandleFunc(apiVerPath+"machines/", func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Path[len(apiVerPath+"machines/"):]
+		switch r.Method {
+		case http.MethodGet:
+			notes := ""
+			if name == "APP02" {
+				notes = "critical, protected"
+			}
+			fmt.Fprintf(w, `{"data":{"name":%q,"status":"Running","notes":%q}}`, name, notes)
+		case http.MethodDelete:
+			deleted = append(deleted, name)
+			writeFixture(t, w, "jobspost.json")
+		default:
+			writeFixture(t, w, "jobspost.json")
+		}
+	})
+
+	confirmed := []string{}
+	require.NoError(t, Guard(&GuardOptions{
+		Deny:         []string{"PROD*"},
+		Allow:        []string{"APP*", "PROD*"},
+		ProtectedTag: "protected",
+		MaxCalls:     2,
+		Confirm: func(op, machine string) (bool, error) {
+			confirmed = append(confirmed, op+" "+machine)
+			return machine != "APP03", nil
+		},
+	})(client))
+
+	_, err := client.DeleteMachine("PROD01")
+	require.EqualError(t, err, `DeleteMachine of PROD01 denied: matches deny pattern "PROD*"`)
+	_, err = client.DeleteMachine("DEV01")
+	require.EqualError(t, err, "DeleteMachine of DEV01 denied: doesn't match allow patterns")
+	_, err = client.ReinstallMachine("APP02", &ReinstallMachineOptions{TemplateID: 1})
+	require.EqualError(t, err, "ReinstallMachine of APP02 denied: machine is protected")
+	_, err = client.DeleteMachine("APP03")
+	require.EqualError(t, err, "DeleteMachine of APP03 denied: not confirmed")
+
+	// not guarded operations pass
+	_, err = client.SendMachineCommand("PROD01", "restart")
+	require.NoError(t, err)
+
+	_, err = client.DeleteMachine("APP01")
+	require.NoError(t, err)
+	_, err = client.ReinstallMachine("APP01", &ReinstallMachineOptions{TemplateID: 1})
+	require.NoError(t, err)
+	_, err = client.DeleteMachine("APP04")
+	require.EqualError(t, err, "DeleteMachine of APP04 denied: limit of 2 destructive calls reached")
+
+	require.Equal(t, []string{"APP01"}, deleted)
+	require.Equal(t, []string{"DeleteMachine APP03", "DeleteMachine APP01", "ReinstallMachine APP01"}, confirmed)
+
+	require.NoError(t, Guard(nil)(client))
+	_, err = client.DeleteMachine("PROD01")
+	require.NoError(t, err)
+
+	require.Error(t, Guard(&GuardOptions{Deny: []string{"["}})(client))
+}
+
+func TestGuardBulk(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	mux.HandleFunc(apiVerPath+"machines", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"name":"VPS01","notes":""},{"name":"VPS02","notes":"keep: protected"}],"pagination":{"total":2,"limit":50,"page":1,"pages":1}}`)
+	})
+	mux.HandleFunc(apiVerPath+"machines/VPS01/restart", func(w http.ResponseWriter, r *http.Request) {
+		writeFixture(t, w, "jobspost.json")
+	})
+	mux.HandleFunc(apiVerPath+"machines/VPS02/restart", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("protected machine must be skipped")
+	})
+
+	require.NoError(t, Guard(&GuardOptions{ProtectedTag: "protected"})(client))
+	protected, err := client.ProtectedMachines()
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"VPS02": true}, protected)
+
+	results := client.BulkCommand([]string{"VPS01", "VPS02"}, "restart", nil)
+	require.True(t, results[0].Success)
+	require.True(t, results[1].Skipped)
+	require.Empty(t, results[1].Error)
+	require.Equal(t, BulkResults{results[1]}, results.Skipped())
+	require.Empty(t, results.Failed())
+	require.NoError(t, results.Err())
+}
+
+func TestGuardRollout(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	f := &fakeFleet{reboot: map[string]bool{}, failing: map[string]bool{}}
+	f.register(mux)
+	lists := 0
+	mux.HandleFunc(apiVerPath+"machines", func(w http.ResponseWriter, r *http.Request) {
+		lists++
+		fmt.Fprint(w, `{"data":[{"name":"VPS01","notes":"protected"},{"name":"VPS03","notes":"protected"}],"pagination":{"total":2,"limit":50,"page":1,"pages":1}}`)
+	})
+
+	require.NoError(t, Guard(&GuardOptions{ProtectedTag: "protected"})(client))
+	names := []string{"VPS01", "VPS02", "VPS03", "VPS04"}
+	results, err := client.Rollout(names, "restart", &RolloutOptions{Interval: time.Millisecond})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	require.Len(t, results.Skipped(), 2)
+	require.Equal(t, []string{"VPS02 restart", "VPS04 restart"}, f.commands)
+	require.Equal(t, 1, lists)
+}
+
+func TestGuardDryRun(t *testing.T) {
+	_, server, client := setup(t)
+	defer teardown(server)
+
+	require.NoError(t, DryRun(true)(client))
+	require.NoError(t, Guard(&GuardOptions{
+		Deny:     []string{"PROD*"},
+		MaxCalls: 1,
+		Confirm: func(op, machine string) (bool, error) {
+			t.Errorf("confirmation must not be asked in dry-run mode: %s %s", op, machine)
+			return false, nil
+		},
+	})(client))
+
+	_, err := client.DeleteMachine("VPS01")
+	require.NoError(t, err)
+	_, err = client.DeleteMachine("VPS02")
+	require.NoError(t, err)
+	_, err = client.DeleteMachine("PROD01")
+	require.EqualError(t, err, `DeleteMachine of PROD01 denied: matches deny pattern "PROD*"`)
+	require.Len(t, client.DryRunRequests(), 2)
+}
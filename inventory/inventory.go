@@ -0,0 +1,244 @@
+// Package inventory builds Ansible inventories of winvps machines.
+//
+// Hosts are grouped by status, template and product, e.g. status_running, template_1 and
+// product_win_2cpu. Product is found by matching machine config against product limits, see
+// winvps.MatchProduct(). The api doesn't report machine location, so there are no location groups.
+// Static YAML and INI inventories and dynamic inventory JSON for --list and --host are supported.
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fozzyhosting/winvps-go-client"
+	"gopkg.in/yaml.v3"
+)
+
+// Name of the product group of machines whose config doesn't match any product limits
+const CustomProduct = "custom"
+
+// Represents an inventory of machines
+type Inventory struct {
+	// Hosts keyed by group name
+	Groups map[string][]string
+	// Host variables keyed by host name
+	HostVars map[string]map[string]interface{}
+	// Variables of all hosts, e.g. ansible_connection: winrm
+	Vars map[string]interface{}
+}
+
+// Fetch all machines and products and build inventory
+func Fetch(c *winvps.Client) (*Inventory, error) {
+	machines, err := winvps.ListAll(c.GetMachinesFull)
+	if err != nil {
+		return nil, err
+	}
+	products, err := winvps.ListAll(c.GetProducts)
+	if err != nil {
+		return nil, err
+	}
+	return Build(machines, products), nil
+}
+
+// Build inventory of machines, products are used to group machines by product
+func Build(machines []*winvps.MachineFull, products []*winvps.Product) *Inventory {
+	inv := &Inventory{
+		Groups:   map[string][]string{},
+		HostVars: map[string]map[string]interface{}{},
+		Vars:     map[string]interface{}{},
+	}
+	for _, m := range machines {
+		if m.Machine == nil {
+			continue
+		}
+		product := CustomProduct
+		if p := winvps.MatchProduct(m.Config, products); p != nil {
+			product = p.Name
+		}
+		inv.add("status_"+m.Status, m.Name)
+		inv.add("product_"+product, m.Name)
+
+		vars := map[string]interface{}{
+			"winvps_status":  m.Status,
+			"winvps_notes":   m.Notes,
+			"winvps_product": product,
+		}
+		ipv4, ipv6 := []string{}, []string{}
+		for _, ip := range m.IPs {
+			if ip.Version == 6 {
+				ipv6 = append(ipv6, ip.Address)
+			} else {
+				ipv4 = append(ipv4, ip.Address)
+			}
+		}
+		vars["winvps_ipv4"], vars["winvps_ipv6"] = ipv4, ipv6
+		if len(ipv4) > 0 {
+			vars["ansible_host"] = ipv4[0]
+		} else if len(ipv6) > 0 {
+			vars["ansible_host"] = ipv6[0]
+		}
+		if m.OS != nil {
+			inv.add("template_"+m.OS.TemplateID, m.Name)
+			vars["winvps_template_id"] = m.OS.TemplateID
+			vars["winvps_brand_id"] = m.OS.BrandID
+			if u := m.OS.UpdateStatus; u != nil {
+				vars["winvps_reboot_required"] = u.RebootRequired
+			}
+		}
+		if l := m.Config; l != nil {
+			vars["winvps_limits"] = map[string]interface{}{
+				"cpu_cores":   l.CpuCores,
+				"cpu_percent": l.CpuPercent,
+				"ram_min":     l.RamMin,
+				"ram_max":     l.RamMax,
+				"disk_size":   l.DiskSize,
+				"bandwidth":   l.Bandwidth,
+			}
+		}
+		inv.HostVars[m.Name] = vars
+	}
+	return inv
+}
+
+var invalidGroupChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+func (inv *Inventory) add(group, host string) {
+	group = invalidGroupChars.ReplaceAllString(strings.ToLower(group), "_")
+	inv.Groups[group] = append(inv.Groups[group], host)
+}
+
+// Returns sorted group names
+func (inv *Inventory) groupNames() []string {
+	return sortedKeys(inv.Groups)
+}
+
+// Write dynamic inventory JSON returned for --list
+func (inv *Inventory) WriteList(w io.Writer) error {
+	out := map[string]interface{}{
+		"_meta": map[string]interface{}{"hostvars": inv.HostVars},
+		"all": map[string]interface{}{
+			"children": inv.groupNames(),
+			"vars":     inv.Vars,
+		},
+	}
+	for group, hosts := range inv.Groups {
+		out[group] = map[string]interface{}{"hosts": hosts}
+	}
+	return writeJSON(w, out)
+}
+
+// Write dynamic inventory JSON returned for --host, that is variables of the host
+func (inv *Inventory) WriteHost(w io.Writer, host string) error {
+	vars, ok := inv.HostVars[host]
+	if !ok {
+		return fmt.Errorf("host %s not found", host)
+	}
+	return writeJSON(w, vars)
+}
+
+// Write static YAML inventory
+func (inv *Inventory) WriteYAML(w io.Writer) error {
+	hosts := map[string]interface{}{}
+	for host, vars := range inv.HostVars {
+		hosts[host] = vars
+	}
+	children := map[string]interface{}{}
+	for group, groupHosts := range inv.Groups {
+		members := map[string]interface{}{}
+		for _, host := range groupHosts {
+			members[host] = nil
+		}
+		children[group] = map[string]interface{}{"hosts": members}
+	}
+	all := map[string]interface{}{"hosts": hosts, "children": children}
+	if len(inv.Vars) > 0 {
+		all["vars"] = inv.Vars
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(map[string]interface{}{"all": all}); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// Write static INI inventory, lists and maps are written as literals parsed by Ansible
+func (inv *Inventory) WriteINI(w io.Writer) error {
+	b := new(strings.Builder)
+	for _, host := range sortedKeys(inv.HostVars) {
+		b.WriteString(host)
+		vars := inv.HostVars[host]
+		for _, k := range sortedKeys(vars) {
+			fmt.Fprintf(b, " %s=%s", k, iniValue(vars[k]))
+		}
+		b.WriteString("\n")
+	}
+	if len(inv.Vars) > 0 {
+		b.WriteString("\n[all:vars]\n")
+		for _, k := range sortedKeys(inv.Vars) {
+			fmt.Fprintf(b, "%s=%s\n", k, iniValue(inv.Vars[k]))
+		}
+	}
+	for _, group := range inv.groupNames() {
+		fmt.Fprintf(b, "\n[%s]\n", group)
+		for _, host := range inv.Groups[group] {
+			b.WriteString(host + "\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Returns value as INI inventory literal, booleans are written as python literals.
+// Strings with characters other than safe ones are quoted, lists and maps are written as
+// single quoted json
+func iniValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		if v != "" && strings.IndexFunc(v, unsafeINIRune) < 0 {
+			return v
+		}
+		return strconv.Quote(v)
+	case bool:
+		if v {
+			return "True"
+		}
+		return "False"
+	}
+	data, _ := json.Marshal(v)
+	if len(data) > 0 && (data[0] == '[' || data[0] == '{') {
+		// ansible shlex-splits host lines, single quotes keep inner double quotes of lists
+		// and maps, single quote can appear only within json strings, so it's escaped there
+		return "'" + strings.ReplaceAll(string(data), "'", `\u0027`) + "'"
+	}
+	return string(data)
+}
+
+// Reports whether the rune requires quoting of INI value
+func unsafeINIRune(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return false
+	}
+	return !strings.ContainsRune("._-:/@,+", r)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,143 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/fozzyhosting/winvps-go-client"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func testInventory() *Inventory {
+	products := []*winvps.Product{{ID: 1, Name: "Win 2CPU", Limits: &winvps.Limits{CpuCores: 2, RamMax: 2048}}}
+	machines := []*winvps.MachineFull{
+		{
+			Machine: &winvps.Machine{Name: "VPS01", Status: "Running", Notes: "web 1"},
+			IPs:     []*winvps.IP{{Version: 4, Address: "10.0.0.1"}, {Version: 6, Address: "2001:db8::1"}},
+			OS:      &winvps.OS{TemplateID: "1", BrandID: 2, UpdateStatus: &winvps.UpdateStatus{RebootRequired: true}},
+			Config:  &winvps.Limits{CpuCores: 2, RamMax: 2048},
+		},
+		{Machine: &winvps.Machine{Name: "VPS02", Status: "Stopped"}},
+	}
+	inv := Build(machines, products)
+	inv.Vars["ansible_connection"] = "winrm"
+	return inv
+}
+
+func TestBuild(t *testing.T) {
+	inv := testInventory()
+	require.Equal(t, map[string][]string{
+		"status_running":   {"VPS01"},
+		"status_stopped":   {"VPS02"},
+		"product_win_2cpu": {"VPS01"},
+		"product_custom":   {"VPS02"},
+		"template_1":       {"VPS01"},
+	}, inv.Groups)
+	require.Equal(t, map[string]interface{}{
+		"ansible_host":           "10.0.0.1",
+		"winvps_status":          "Running",
+		"winvps_notes":           "web 1",
+		"winvps_product":         "Win 2CPU",
+		"winvps_ipv4":            []string{"10.0.0.1"},
+		"winvps_ipv6":            []string{"2001:db8::1"},
+		"winvps_template_id":     "1",
+		"winvps_brand_id":        2,
+		"winvps_reboot_required": true,
+		"winvps_limits": map[string]interface{}{
+			"cpu_cores": 2, "cpu_percent": 0, "ram_min": 0, "ram_max": 2048, "disk_size": 0, "bandwidth": 0,
+		},
+	}, inv.HostVars["VPS01"])
+}
+
+func TestWriteList(t *testing.T) {
+	inv := testInventory()
+	b := new(bytes.Buffer)
+	require.NoError(t, inv.WriteList(b))
+
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(b.Bytes(), &out))
+	require.Equal(t, map[string]interface{}{
+		"children": []interface{}{"product_custom", "product_win_2cpu", "status_running", "status_stopped", "template_1"},
+		"vars":     map[string]interface{}{"ansible_connection": "winrm"},
+	}, out["all"])
+	require.Equal(t, map[string]interface{}{"hosts": []interface{}{"VPS02"}}, out["status_stopped"])
+	hostvars := out["_meta"].(map[string]interface{})["hostvars"].(map[string]interface{})
+	require.Len(t, hostvars, 2)
+
+	b.Reset()
+	require.NoError(t, inv.WriteHost(b, "VPS02"))
+	require.JSONEq(t, `{"winvps_status":"Stopped","winvps_notes":"","winvps_product":"custom","winvps_ipv4":[],"winvps_ipv6":[]}`, b.String())
+	require.EqualError(t, inv.WriteHost(b, "VPS03"), "host VPS03 not found")
+}
+
+func TestWriteYAML(t *testing.T) {
+	b := new(bytes.Buffer)
+	require.NoError(t, testInventory().WriteYAML(b))
+
+	var out struct {
+		All struct {
+			Hosts    map[string]map[string]interface{}
+			Children map[string]struct {
+				Hosts map[string]interface{}
+			}
+			Vars map[string]interface{}
+		}
+	}
+	require.NoError(t, yaml.Unmarshal(b.Bytes(), &out))
+	require.Equal(t, "10.0.0.1", out.All.Hosts["VPS01"]["ansible_host"])
+	require.Contains(t, out.All.Children["status_running"].Hosts, "VPS01")
+	require.Equal(t, "winrm", out.All.Vars["ansible_connection"])
+}
+
+func TestWriteINI(t *testing.T) {
+	b := new(bytes.Buffer)
+	require.NoError(t, testInventory().WriteINI(b))
+	require.Equal(t, `VPS01 ansible_host=10.0.0.1 winvps_brand_id=2 winvps_ipv4='["10.0.0.1"]' winvps_ipv6='["2001:db8::1"]' winvps_limits='{"bandwidth":0,"cpu_cores":2,"cpu_percent":0,"disk_size":0,"ram_max":2048,"ram_min":0}' winvps_notes="web 1" winvps_product="Win 2CPU" winvps_reboot_required=True winvps_status=Running winvps_template_id=1
+VPS02 winvps_ipv4='[]' winvps_ipv6='[]' winvps_notes="" winvps_product=custom winvps_status=Stopped
+
+[all:vars]
+ansible_connection=winrm
+
+[product_custom]
+VPS02
+
+[product_win_2cpu]
+VPS01
+
+[status_running]
+VPS01
+
+[status_stopped]
+VPS02
+
+[template_1]
+VPS01
+`, b.String())
+}
+
+func TestINIValue(t *testing.T) {
+	for value, want := range map[string]string{
+		"Running":              "Running",
+		"10.0.0.1":             "10.0.0.1",
+		"":                     `""`,
+		"web 1":                `"web 1"`,
+		"line 1\nline 2":       `"line 1\nline 2"`,
+		"dos\r\nnotes":         `"dos\r\nnotes"`,
+		"tag[prod]":            `"tag[prod]"`,
+		"key=value;# comment":  `"key=value;# comment"`,
+		"multi\nline\nnotes\n": `"multi\nline\nnotes\n"`,
+	} {
+		require.Equal(t, want, iniValue(value), value)
+	}
+	require.Equal(t, `'["10.0.0.1"]'`, iniValue([]string{"10.0.0.1"}))
+	require.Equal(t, `'{"note":"it\u0027s"}'`, iniValue(map[string]string{"note": "it's"}))
+	require.Equal(t, "2", iniValue(2))
+
+	inv := testInventory()
+	inv.HostVars["VPS02"]["winvps_notes"] = "first line\nsecond line"
+	b := new(bytes.Buffer)
+	require.NoError(t, inv.WriteINI(b))
+	require.Contains(t, b.String(), `VPS02 winvps_ipv4='[]' winvps_ipv6='[]' winvps_notes="first line\nsecond line" winvps_product=custom winvps_status=Stopped`+"\n")
+}
@@ -0,0 +1,145 @@
+package winvps
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Represents a machine IP matched by IPIndex search
+type IPMatch struct {
+	IP      string       `json:"ip"`
+	Machine *MachineFull `json:"machine"`
+}
+
+// Represents a cached index of machine IPs, safe for concurrent use
+type IPIndex struct {
+	client *Client
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	matches []*IPMatch
+	byIP    map[string]*IPMatch
+	fetched time.Time
+}
+
+// Returns IP index of all machines, machines are fetched on first use and refetched when
+// the index is older than ttl, 1 minute by default
+func NewIPIndex(c *Client, ttl time.Duration) *IPIndex {
+	if ttl == 0 {
+		ttl = time.Minute
+	}
+	return &IPIndex{client: c, ttl: ttl}
+}
+
+// Fetch all machines and rebuild the index
+func (ix *IPIndex) Refresh() error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	return ix.refresh()
+}
+
+func (ix *IPIndex) refresh() error {
+	machines, err := ListAll(ix.client.GetMachinesFull)
+	if err != nil {
+		return err
+	}
+	ix.matches, ix.byIP = nil, map[string]*IPMatch{}
+	for _, m := range machines {
+		if m.Machine == nil {
+			continue
+		}
+		for _, ip := range m.IPs {
+			addr := net.ParseIP(ip.Address)
+			if addr == nil {
+				continue
+			}
+			match := &IPMatch{IP: addr.String(), Machine: m}
+			ix.matches = append(ix.matches, match)
+			ix.byIP[match.IP] = match
+		}
+	}
+	ix.fetched = time.Now()
+	return nil
+}
+
+// Returns index entries, refreshing them when expired
+func (ix *IPIndex) entries() ([]*IPMatch, map[string]*IPMatch, error) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.byIP == nil || time.Since(ix.fetched) > ix.ttl {
+		if err := ix.refresh(); err != nil {
+			return nil, nil, err
+		}
+	}
+	return ix.matches, ix.byIP, nil
+}
+
+// Returns machine owning the IP. Any address form is accepted, e.g. 10.0.0.1, ::ffff:10.0.0.1,
+// 2001:DB8::0001, [2001:db8::1] or 10.0.0.1:3389
+func (ix *IPIndex) Lookup(ip string) (*MachineFull, error) {
+	addr, err := parseIP(ip)
+	if err != nil {
+		return nil, err
+	}
+	_, byIP, err := ix.entries()
+	if err != nil {
+		return nil, err
+	}
+	match, ok := byIP[addr.String()]
+	if !ok {
+		return nil, fmt.Errorf("no machine with IP %s", addr)
+	}
+	return match.Machine, nil
+}
+
+// Returns machine IPs within CIDR or equal to IP address, a machine is returned for each
+// of its matched IPs
+func (ix *IPIndex) Search(query string) ([]*IPMatch, error) {
+	var network *net.IPNet
+	if strings.Contains(query, "/") {
+		var err error
+		if network, err = parseNetwork(query); err != nil {
+			return nil, err
+		}
+	} else {
+		addr, err := parseIP(query)
+		if err != nil {
+			return nil, err
+		}
+		if network, err = parseNetwork(addr.String()); err != nil {
+			return nil, err
+		}
+	}
+
+	matches, _, err := ix.entries()
+	if err != nil {
+		return nil, err
+	}
+	var found []*IPMatch
+	for _, m := range matches {
+		if network.Contains(net.ParseIP(m.IP)) {
+			found = append(found, m)
+		}
+	}
+	return found, nil
+}
+
+// Parse IP address, brackets, zone and port are stripped
+func parseIP(s string) (net.IP, error) {
+	s = strings.TrimSpace(s)
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	if i := strings.IndexByte(s, '%'); i >= 0 {
+		s = s[:i]
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address '%s'", s)
+	}
+	return ip, nil
+}
@@ -0,0 +1,67 @@
+package winvps
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPIndex(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	requests := 0
+	mux.HandleFunc(apiVerPath+"machines/full", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"data":[
+			{"name":"VPS01","ips":[{"version":4,"address":"10.0.0.1"},{"version":6,"address":"2001:db8::1"}]},
+			{"name":"VPS02","ips":[{"version":4,"address":"10.0.0.2"}]}
+		],"pagination":{"total":2,"limit":50,"page":1,"pages":1}}`)
+	})
+
+	ix := NewIPIndex(client, time.Hour)
+	for _, ip := range []string{"10.0.0.1", " ::ffff:10.0.0.1", "2001:DB8:0::0001", "[2001:db8::1]", "[2001:db8::1]:3389", "10.0.0.1:3389", "2001:db8::1%eth0"} {
+		m, err := ix.Lookup(ip)
+		require.NoError(t, err, ip)
+		require.Equal(t, "VPS01", m.Name, ip)
+	}
+	require.Equal(t, 1, requests)
+
+	_, err := ix.Lookup("10.0.0.3")
+	require.EqualError(t, err, "no machine with IP 10.0.0.3")
+	_, err = ix.Lookup("10.0.0")
+	require.EqualError(t, err, "invalid IP address '10.0.0'")
+
+	matches, err := ix.Search("10.0.0.0/30")
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	require.Equal(t, "10.0.0.1", matches[0].IP)
+	require.Equal(t, "VPS02", matches[1].Machine.Name)
+
+	matches, err = ix.Search("2001:db8::/32")
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	require.Equal(t, "2001:db8::1", matches[0].IP)
+
+	matches, err = ix.Search("::ffff:10.0.0.2")
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	require.Equal(t, "VPS02", matches[0].Machine.Name)
+
+	_, err = ix.Search("10.0.0.0/33")
+	require.EqualError(t, err, "invalid CIDR '10.0.0.0/33'")
+
+	require.NoError(t, ix.Refresh())
+	require.Equal(t, 2, requests)
+
+	ix = NewIPIndex(client, time.Nanosecond)
+	_, err = ix.Lookup("10.0.0.1")
+	require.NoError(t, err)
+	time.Sleep(time.Millisecond)
+	_, err = ix.Lookup("10.0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, 4, requests)
+}
@@ -3,6 +3,15 @@ package winvps
 import (
 	"fmt"
 	"net/http"
+	"time"
+)
+
+// Job statuses
+const (
+	JobStatusPending    = "Pending"
+	JobStatusInprogress = "Inprogress"
+	JobStatusComplete   = "Complete"
+	JobStatusFailed     = "Failed"
 )
 
 // Represents a winvps job
@@ -15,12 +24,17 @@ type Job struct {
 	StartTime string `json:"start_time"`
 }
 
+// Reports whether job is finished, i.e. it's neither pending nor in progress
+func (j *Job) Done() bool {
+	return j.Status != JobStatusPending && j.Status != JobStatusInprogress
+}
+
 // Returns all planned and completed jobs. Info from Pagination can be used to get jobs using RequestOptions
 // default Limit 50
 func (c *Client) GetJobs(opts ...*RequestOptions) ([]*Job, *Pagination, error) {
 	u := "jobs"
 
-	req, err := c.NewRequest(http.MethodGet, u, nil, opts)
+	req, err := c.newRequest("GetJobs", http.MethodGet, u, nil, opts)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -39,7 +53,7 @@ func (c *Client) GetJobs(opts ...*RequestOptions) ([]*Job, *Pagination, error) {
 func (c *Client) GetPendingJobs(opts ...*RequestOptions) ([]*Job, *Pagination, error) {
 	u := "jobs/pending"
 
-	req, err := c.NewRequest(http.MethodGet, u, nil, opts)
+	req, err := c.newRequest("GetPendingJobs", http.MethodGet, u, nil, opts)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -57,7 +71,7 @@ func (c *Client) GetPendingJobs(opts ...*RequestOptions) ([]*Job, *Pagination, e
 func (c *Client) GetJob(id int) (*Job, error) {
 	u := fmt.Sprintf("jobs/%d", id)
 
-	req, err := c.NewRequest(http.MethodGet, u, nil, nil)
+	req, err := c.newRequest("GetJob", http.MethodGet, u, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -75,7 +89,7 @@ func (c *Client) GetJob(id int) (*Job, error) {
 func (c *Client) CancelJob(id int) error {
 	u := fmt.Sprintf("jobs/%d", id)
 
-	req, err := c.NewRequest(http.MethodDelete, u, nil, nil)
+	req, err := c.newRequest("CancelJob", http.MethodDelete, u, nil, nil)
 	if err != nil {
 		return err
 	}
@@ -86,3 +100,51 @@ func (c *Client) CancelJob(id int) error {
 
 	return err
 }
+
+// Default poll interval of WaitJob() and WaitJobs()
+const DefaultJobInterval = 5 * time.Second
+
+// Polls job every interval until it's done, returns the last fetched job info.
+// Non-positive interval means DefaultJobInterval, zero timeout means wait forever
+func (c *Client) WaitJob(id int, interval, timeout time.Duration) (*Job, error) {
+	if interval <= 0 {
+		interval = DefaultJobInterval
+	}
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	for {
+		job, err := c.GetJob(id)
+		if err != nil {
+			return nil, err
+		}
+		if job.Done() {
+			return job, nil
+		}
+		if !deadline.IsZero() && time.Now().Add(interval).After(deadline) {
+			return job, fmt.Errorf("timeout waiting for job %d, status: %s", id, job.Status)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// Waits until all jobs are done, jobs are replaced by their last fetched info in place.
+// Job finished with status other than Complete, e.g. failed or cancelled, is reported as error
+func (c *Client) WaitJobs(jobs []*Job, interval, timeout time.Duration) error {
+	for i, j := range jobs {
+		done, err := c.WaitJob(j.ID, interval, timeout)
+		if err != nil {
+			return err
+		}
+		jobs[i] = done
+		switch done.Status {
+		case JobStatusComplete:
+		case JobStatusFailed:
+			return fmt.Errorf("job %d failed", done.ID)
+		default:
+			return fmt.Errorf("job %d finished with status %s", done.ID, done.Status)
+		}
+	}
+	return nil
+}
@@ -1,8 +1,10 @@
 package winvps
 
 import (
+	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -72,3 +74,56 @@ func TestCancelJob(t *testing.T) {
 	err := client.CancelJob(1)
 	require.NoError(t, err)
 }
+
+func TestWaitJob(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	calls := 0
+	mux.HandleFunc(apiVerPath+"jobs/1", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		status := JobStatusInprogress
+		if calls == 3 {
+			status = JobStatusComplete
+		}
+		fmt.Fprintf(w, `{"data":{"id":1,"status":"%s"}}`, status)
+	})
+	pendingCalls := 0
+	mux.HandleFunc(apiVerPath+"jobs/2", func(w http.ResponseWriter, r *http.Request) {
+		pendingCalls++
+		fmt.Fprint(w, `{"data":{"id":2,"status":"Pending"}}`)
+	})
+
+	got, err := client.WaitJob(1, time.Millisecond, 0)
+	require.NoError(t, err)
+	require.Equal(t, &Job{ID: 1, Status: JobStatusComplete}, got)
+	require.Equal(t, 3, calls)
+
+	got, err = client.WaitJob(2, 10*time.Millisecond, 25*time.Millisecond)
+	require.EqualError(t, err, "timeout waiting for job 2, status: Pending")
+	require.Equal(t, &Job{ID: 2, Status: JobStatusPending}, got)
+
+	// zero interval falls back to the default instead of busy polling
+	pendingCalls = 0
+	_, err = client.WaitJob(2, 0, time.Second)
+	require.Error(t, err)
+	require.Equal(t, 1, pendingCalls)
+}
+
+func TestWaitJobs(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	for id, status := range map[int]string{1: JobStatusComplete, 2: JobStatusFailed, 3: "Cancelled"} {
+		id, status := id, status
+		mux.HandleFunc(fmt.Sprintf("%sjobs/%d", apiVerPath, id), func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"data":{"id":%d,"status":"%s"}}`, id, status)
+		})
+	}
+
+	jobs := []*Job{{ID: 1}}
+	require.NoError(t, client.WaitJobs(jobs, time.Millisecond, 0))
+	require.Equal(t, JobStatusComplete, jobs[0].Status)
+	require.EqualError(t, client.WaitJobs([]*Job{{ID: 1}, {ID: 2}}, time.Millisecond, 0), "job 2 failed")
+	require.EqualError(t, client.WaitJobs([]*Job{{ID: 3}}, time.Millisecond, 0), "job 3 finished with status Cancelled")
+}
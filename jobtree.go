@@ -0,0 +1,169 @@
+package winvps
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Represents a job with its child jobs
+type JobNode struct {
+	*Job
+	Children []*JobNode `json:"children,omitempty"`
+}
+
+// Returns the job followed by all its descendants, depth first
+func (n *JobNode) Jobs() []*Job {
+	jobs := []*Job{n.Job}
+	for _, child := range n.Children {
+		jobs = append(jobs, child.Jobs()...)
+	}
+	return jobs
+}
+
+// Returns aggregate status of the job and all its descendants, see GroupStatus()
+func (n *JobNode) GroupStatus() string {
+	return GroupStatus(n.Jobs())
+}
+
+// Represents a forest of jobs, roots are sorted by ID
+type JobTree []*JobNode
+
+// Build a tree of jobs linked by ParentID. Jobs without parent, being their own parent or
+// whose parent isn't among jobs are roots. Links creating a cycle are ignored
+func BuildJobTree(jobs []*Job) JobTree {
+	nodes := make(map[int]*JobNode, len(jobs))
+	for _, job := range jobs {
+		if job != nil {
+			nodes[job.ID] = &JobNode{Job: job}
+		}
+	}
+	ids := make([]int, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	parents := map[int]int{}
+	var tree JobTree
+	for _, id := range ids {
+		n := nodes[id]
+		parent, ok := nodes[n.ParentID]
+		if !ok || n.ParentID == id || cyclic(parents, n.ParentID, id) {
+			tree = append(tree, n)
+			continue
+		}
+		parents[id] = n.ParentID
+		parent.Children = append(parent.Children, n)
+	}
+	return tree
+}
+
+// Reports whether linking id to parent creates a cycle
+func cyclic(parents map[int]int, parent, id int) bool {
+	for p, ok := parent, true; ok; p, ok = parents[p] {
+		if p == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns node of the job, nil if there is none
+func (t JobTree) Find(id int) *JobNode {
+	for _, n := range t {
+		if n.ID == id {
+			return n
+		}
+		if found := JobTree(n.Children).Find(id); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// Returns aggregate status of all jobs in the tree, see GroupStatus()
+func (t JobTree) GroupStatus() string {
+	var jobs []*Job
+	for _, n := range t {
+		jobs = append(jobs, n.Jobs()...)
+	}
+	return GroupStatus(jobs)
+}
+
+// Returns the tree rendered as text, a job per line with its children indented below
+func (t JobTree) String() string {
+	sb := &strings.Builder{}
+	for _, n := range t {
+		writeJobNode(sb, n, "", "")
+	}
+	return sb.String()
+}
+
+func writeJobNode(sb *strings.Builder, n *JobNode, prefix, childPrefix string) {
+	fmt.Fprintf(sb, "%s%d %s %s", prefix, n.ID, n.Type, n.Status)
+	if n.MachineID != 0 {
+		fmt.Fprintf(sb, " machine %d", n.MachineID)
+	}
+	if n.StartTime != "" {
+		fmt.Fprintf(sb, " started %s", n.StartTime)
+	}
+	if len(n.Children) > 0 {
+		fmt.Fprintf(sb, " [group %s]", n.GroupStatus())
+	}
+	sb.WriteString("\n")
+	for i, child := range n.Children {
+		if i == len(n.Children)-1 {
+			writeJobNode(sb, child, childPrefix+"└── ", childPrefix+"    ")
+		} else {
+			writeJobNode(sb, child, childPrefix+"├── ", childPrefix+"│   ")
+		}
+	}
+}
+
+// Returns aggregate status of a job group: Failed if any job failed, Pending if all jobs are
+// pending, Inprogress if any job isn't done, Complete if all jobs are complete. Otherwise status
+// of the first finished but not complete job is returned, e.g. of a cancelled one
+func GroupStatus(jobs []*Job) string {
+	pending, running := 0, 0
+	other := ""
+	for _, job := range jobs {
+		switch job.Status {
+		case JobStatusFailed:
+			return JobStatusFailed
+		case JobStatusPending:
+			pending++
+		case JobStatusInprogress:
+			running++
+		case JobStatusComplete:
+		default:
+			if other == "" {
+				other = job.Status
+			}
+		}
+	}
+	switch {
+	case len(jobs) > 0 && pending == len(jobs):
+		return JobStatusPending
+	case pending+running > 0:
+		return JobStatusInprogress
+	case other != "":
+		return other
+	}
+	return JobStatusComplete
+}
+
+// Fetch all jobs, or jobs of the machine if name isn't empty, and build their tree
+func (c *Client) GetJobTree(machine string) (JobTree, error) {
+	fetch := c.GetJobs
+	if machine != "" {
+		fetch = func(opts ...*RequestOptions) ([]*Job, *Pagination, error) {
+			return c.GetMachineJobs(machine, opts...)
+		}
+	}
+	jobs, err := ListAll(fetch)
+	if err != nil {
+		return nil, err
+	}
+	return BuildJobTree(jobs), nil
+}
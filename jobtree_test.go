@@ -0,0 +1,71 @@
+package winvps
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildJobTree(t *testing.T) {
+	jobs := []*Job{
+		{ID: 4, ParentID: 2, MachineID: 7, Type: "Start", Status: JobStatusPending},
+		{ID: 1, ParentID: 1, MachineID: 7, Type: "Initialize", Status: JobStatusComplete, StartTime: "2020-10-20 01:02:03"},
+		{ID: 2, ParentID: 1, MachineID: 7, Type: "Change", Status: JobStatusInprogress},
+		{ID: 3, ParentID: 1, MachineID: 7, Type: "Restart", Status: JobStatusComplete},
+		{ID: 5, ParentID: 9, Type: "Backup", Status: JobStatusFailed},
+		{ID: 6, ParentID: 7, Type: "A", Status: JobStatusComplete},
+		{ID: 7, ParentID: 6, Type: "B", Status: JobStatusComplete},
+	}
+	tree := BuildJobTree(jobs)
+	require.Len(t, tree, 3)
+	require.Equal(t, []int{1, 5, 7}, []int{tree[0].ID, tree[1].ID, tree[2].ID})
+	require.Equal(t, 4, tree.Find(4).ID)
+	require.Nil(t, tree.Find(10))
+	require.Len(t, tree[0].Jobs(), 4)
+
+	require.Equal(t, JobStatusInprogress, tree[0].GroupStatus())
+	require.Equal(t, JobStatusPending, tree.Find(2).Children[0].GroupStatus())
+	require.Equal(t, JobStatusFailed, tree.GroupStatus())
+	// cycle is broken at the job linked last
+	require.Equal(t, JobStatusComplete, tree[2].GroupStatus())
+
+	require.Equal(t, `1 Initialize Complete machine 7 started 2020-10-20 01:02:03 [group Inprogress]
+├── 2 Change Inprogress machine 7 [group Inprogress]
+│   └── 4 Start Pending machine 7
+└── 3 Restart Complete machine 7
+5 Backup Failed
+7 B Complete [group Complete]
+└── 6 A Complete
+`, tree.String())
+}
+
+func TestGroupStatus(t *testing.T) {
+	require.Equal(t, JobStatusComplete, GroupStatus(nil))
+	require.Equal(t, JobStatusPending, GroupStatus([]*Job{{Status: JobStatusPending}, {Status: JobStatusPending}}))
+	require.Equal(t, JobStatusInprogress, GroupStatus([]*Job{{Status: JobStatusPending}, {Status: JobStatusComplete}}))
+	require.Equal(t, JobStatusFailed, GroupStatus([]*Job{{Status: JobStatusInprogress}, {Status: JobStatusFailed}}))
+	require.Equal(t, "Cancelled", GroupStatus([]*Job{{Status: JobStatusComplete}, {Status: "Cancelled"}}))
+}
+
+func TestGetJobTree(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	mux.HandleFunc(apiVerPath+"jobs", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		writeFixture(t, w, "jobs.json")
+	})
+	mux.HandleFunc(apiVerPath+"machines/VPS0123/jobs", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodGet, r.Method)
+		writeFixture(t, w, "jobs.json")
+	})
+
+	tree, err := client.GetJobTree("")
+	require.NoError(t, err)
+	require.NotEmpty(t, tree)
+
+	machineTree, err := client.GetJobTree("VPS0123")
+	require.NoError(t, err)
+	require.Equal(t, tree, machineTree)
+}
@@ -13,7 +13,7 @@ type Location struct {
 func (c *Client) GetLocations(opts ...*RequestOptions) ([]*Location, *Pagination, error) {
 	u := "locations"
 
-	req, err := c.NewRequest(http.MethodGet, u, nil, opts)
+	req, err := c.newRequest("GetLocations", http.MethodGet, u, nil, opts)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -0,0 +1,121 @@
+package winvps
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const redacted = "[REDACTED]"
+
+// Set structured logger for api client. Each request is logged with method, path, query,
+// status, duration and retry count. The API-KEY header and password fields are always redacted
+func Logger(l *slog.Logger) Option {
+	return func(c *Client) error {
+		c.logger = l
+		return nil
+	}
+}
+
+// Enable logging of request and response headers and bodies, used with Logger() option
+func LogBodies(enabled bool) Option {
+	return func(c *Client) error {
+		c.logBodies = enabled
+		return nil
+	}
+}
+
+// Wrap the round-trip with structured logging
+func (c *Client) logRoundTrip(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, []byte, error) {
+		var reqBody []byte
+		if c.logBodies && req.GetBody != nil {
+			if rc, err := req.GetBody(); err == nil {
+				reqBody, _ = ioutil.ReadAll(rc)
+				rc.Close()
+			}
+		}
+
+		start := time.Now()
+		resp, body, err := next(req)
+
+		ctx := req.Context()
+		attrs := []slog.Attr{
+			slog.String("operation", OperationFromContext(ctx)),
+			slog.String("method", req.Method),
+			slog.String("path", req.URL.Path),
+			slog.String("query", req.URL.RawQuery),
+			slog.Duration("duration", time.Since(start)),
+			slog.Int("retry", retryFromContext(ctx)),
+		}
+		level := slog.LevelInfo
+		if resp != nil {
+			attrs = append(attrs, slog.Int("status", resp.StatusCode))
+			if resp.StatusCode >= http.StatusBadRequest {
+				level = slog.LevelError
+			}
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+			level = slog.LevelError
+		}
+		if c.logBodies {
+			attrs = append(attrs,
+				slog.Any("request_headers", redactHeaders(req.Header)),
+				slog.String("request_body", string(redactJSON(reqBody))),
+				slog.String("response_body", string(redactJSON(body))),
+			)
+		}
+		c.logger.LogAttrs(ctx, level, "winvps request", attrs...)
+
+		return resp, body, err
+	}
+}
+
+// Returns a copy of headers with API-KEY value redacted
+func redactHeaders(h http.Header) http.Header {
+	h = h.Clone()
+	if h.Get("API-KEY") != "" {
+		h.Set("API-KEY", redacted)
+	}
+	return h
+}
+
+// Returns a copy of json data with all "password" fields redacted,
+// non json data returned as is
+func redactJSON(data []byte) []byte {
+	if len(data) == 0 {
+		return data
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return data
+	}
+	out, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+// Walk decoded json value and replace password fields
+func redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			if strings.EqualFold(k, "password") {
+				t[k] = redacted
+				continue
+			}
+			t[k] = redactValue(val)
+		}
+	case []interface{}:
+		for i, val := range t {
+			t[i] = redactValue(val)
+		}
+	}
+	return v
+}
@@ -0,0 +1,75 @@
+package winvps
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogger(t *testing.T) {
+	mux, server, _ := setup(t)
+	defer teardown(server)
+
+	mux.HandleFunc(apiVerPath+"machines", func(w http.ResponseWriter, r *http.Request) {
+		writeFixture(t, w, "machines.json")
+	})
+
+	buf := new(bytes.Buffer)
+	client, err := NewClient("secret", BaseURL(server.URL), Logger(slog.New(slog.NewJSONHandler(buf, nil))))
+	require.NoError(t, err)
+
+	_, _, err = client.GetMachines(&RequestOptions{Limit: 1})
+	require.NoError(t, err)
+
+	record := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "INFO", record["level"])
+	require.Equal(t, "GetMachines", record["operation"])
+	require.Equal(t, "GET", record["method"])
+	require.Equal(t, apiVerPath+"machines", record["path"])
+	require.Equal(t, "limit=1", record["query"])
+	require.Equal(t, float64(200), record["status"])
+	require.Equal(t, float64(0), record["retry"])
+	require.Contains(t, record, "duration")
+	require.NotContains(t, record, "request_body")
+}
+
+func TestLoggerRedactsSecrets(t *testing.T) {
+	mux, server, _ := setup(t)
+	defer teardown(server)
+
+	mux.HandleFunc(apiVerPath+"machines", func(w http.ResponseWriter, r *http.Request) {
+		writeFixture(t, w, "machinecreate.json")
+	})
+	mux.HandleFunc(apiVerPath+"machines/VPS0123/users", func(w http.ResponseWriter, r *http.Request) {
+		writeFixture(t, w, "users.json")
+	})
+	mux.HandleFunc(apiVerPath+"machines/VPS0123/change_password", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"weak password"}`))
+	})
+
+	buf := new(bytes.Buffer)
+	client, err := NewClient("api-token", BaseURL(server.URL), Logger(slog.New(slog.NewJSONHandler(buf, nil))), LogBodies(true))
+	require.NoError(t, err)
+
+	_, _, err = client.CreateMachine(&CreateMachineOptions{ProductID: 1, TemplateID: 1, LocationID: 1, Password: "Create-Pass1"})
+	require.NoError(t, err)
+	_, _, err = client.GetMachineUsers("VPS0123")
+	require.NoError(t, err)
+	_, err = client.ChangeMachinePassword("VPS0123", "New-Pass1")
+	require.Error(t, err)
+
+	out := buf.String()
+	require.Contains(t, out, `"request_body":"{\"location_id\":1,\"password\":\"[REDACTED]\"`)
+	require.Contains(t, out, `\"username\":\"admin\"`)
+	require.Contains(t, out, `"level":"ERROR"`)
+	require.Contains(t, out, `"Api-Key":["[REDACTED]"]`)
+	for _, secret := range []string{"api-token", "Create-Pass1", "New-Pass1", `\"secret\"`} {
+		require.NotContains(t, out, secret)
+	}
+}
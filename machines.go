@@ -2,14 +2,21 @@ package winvps
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 )
 
+// Machine statuses
+const (
+	MachineStatusRunning = "Running"
+	MachineStatusStopped = "Stopped"
+)
+
 // List of available CreateMachine() options
 type CreateMachineOptions struct {
 	Description string `json:"description,omitempty"`
-	Password    string `json:"password,omitempty"`
+	Password    Secret `json:"password,omitempty"`
 	ProductID   int    `json:"product_id,omitempty"`
 	TemplateID  int    `json:"template_id,omitempty"`
 	BrandID     int    `json:"brand_id,omitempty"`
@@ -26,7 +33,7 @@ type CreateMachineOptions struct {
 
 // List of available UpdateMachine() options
 type UpdateMachineOptions struct {
-	Password  string `json:"password,omitempty"`
+	Password  Secret `json:"password,omitempty"`
 	ProductID int    `json:"product_id,omitempty"`
 	AddDisk   int    `json:"add_disk,omitempty"`
 	AddRam    int    `json:"add_ram,omitempty"`
@@ -36,7 +43,7 @@ type UpdateMachineOptions struct {
 
 // List of available ReinstallMachine() options
 type ReinstallMachineOptions struct {
-	Password   string `json:"password,omitempty"`
+	Password   Secret `json:"password,omitempty"`
 	TemplateID int    `json:"template_id,omitempty"`
 	BrandID    int    `json:"brand_id,omitempty"`
 	AutoStart  int    `json:"auto_start,omitempty"`
@@ -80,7 +87,7 @@ type UpdateStatus struct {
 type User struct {
 	Username string `json:"username"`
 	Role     string `json:"role"`
-	Password string `json:"password"`
+	Password Secret `json:"password"`
 }
 
 // Represents a simple result response
@@ -90,12 +97,42 @@ type result struct {
 
 // Represents a change_password request
 type password struct {
-	Password string `json:"password"`
+	Password Secret `json:"password"`
 }
 
 type AdditionalUser struct {
 	Username string `json:"username"`
-	Password string `json:"password"`
+	Password Secret `json:"password"`
+}
+
+// LogValue implements slog.LogValuer, password is redacted
+func (t CreateMachineOptions) LogValue() slog.Value {
+	return structLogValue(t)
+}
+
+// LogValue implements slog.LogValuer, password is redacted
+func (t UpdateMachineOptions) LogValue() slog.Value {
+	return structLogValue(t)
+}
+
+// LogValue implements slog.LogValuer, password is redacted
+func (t ReinstallMachineOptions) LogValue() slog.Value {
+	return structLogValue(t)
+}
+
+// LogValue implements slog.LogValuer, password is redacted
+func (u User) LogValue() slog.Value {
+	return structLogValue(u)
+}
+
+// LogValue implements slog.LogValuer, password is redacted
+func (u AdditionalUser) LogValue() slog.Value {
+	return structLogValue(u)
+}
+
+// LogValue implements slog.LogValuer, password is redacted
+func (p password) LogValue() slog.Value {
+	return structLogValue(p)
 }
 
 // Validate CreateMachineOptions for required options
@@ -107,14 +144,38 @@ func (t *CreateMachineOptions) Validate() error {
 	if len(t.DiskType) > 0 && (t.DiskType != "hdd" && t.DiskType != "ssd") {
 		return fmt.Errorf("allowed disk type 'hdd' or 'ssd' but '%s' passed", t.DiskType)
 	}
+	if t.Password != "" {
+		return ValidatePassword(t.Password.Reveal())
+	}
+	return nil
+}
+
+// Validate UpdateMachineOptions password complexity if it's set
+func (t *UpdateMachineOptions) Validate() error {
+	if t != nil && t.Password != "" {
+		return ValidatePassword(t.Password.Reveal())
+	}
+	return nil
+}
+
+// Validate ReinstallMachineOptions password complexity if it's set
+func (t *ReinstallMachineOptions) Validate() error {
+	if t != nil && t.Password != "" {
+		return ValidatePassword(t.Password.Reveal())
+	}
 	return nil
 }
 
+// Validate password complexity
+func (t *password) Validate() error {
+	return ValidatePassword(t.Password.Reveal())
+}
+
 // Create a new machine with specified CreateMachineOptions
 // returns new machine name and Jobs list
 func (c *Client) CreateMachine(opt *CreateMachineOptions) (string, []*Job, error) {
 	u := "machines"
-	req, err := c.NewRequest(http.MethodPost, u, opt, nil)
+	req, err := c.newRequest("CreateMachine", http.MethodPost, u, opt, nil)
 	if err != nil {
 		return "", nil, err
 	}
@@ -134,7 +195,7 @@ func (c *Client) CreateMachine(opt *CreateMachineOptions) (string, []*Job, error
 func (c *Client) UpdateMachine(name string, opt *UpdateMachineOptions) ([]*Job, error) {
 	u := fmt.Sprintf("machines/%s", url.PathEscape(name))
 
-	req, err := c.NewRequest(http.MethodPut, u, opt, nil)
+	req, err := c.newRequest("UpdateMachine", http.MethodPut, u, opt, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -153,7 +214,7 @@ func (c *Client) UpdateMachine(name string, opt *UpdateMachineOptions) ([]*Job,
 func (c *Client) ReinstallMachine(name string, opt *ReinstallMachineOptions) ([]*Job, error) {
 	u := fmt.Sprintf("machines/%s", url.PathEscape(name))
 
-	req, err := c.NewRequest(http.MethodPost, u, opt, nil)
+	req, err := c.newRequest("ReinstallMachine", http.MethodPost, u, opt, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -173,7 +234,7 @@ func (c *Client) ReinstallMachine(name string, opt *ReinstallMachineOptions) ([]
 func (c *Client) GetMachines(opts ...*RequestOptions) ([]*Machine, *Pagination, error) {
 	u := "machines"
 
-	req, err := c.NewRequest(http.MethodGet, u, nil, opts)
+	req, err := c.newRequest("GetMachines", http.MethodGet, u, nil, opts)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -192,7 +253,7 @@ func (c *Client) GetMachines(opts ...*RequestOptions) ([]*Machine, *Pagination,
 func (c *Client) GetMachinesFull(opts ...*RequestOptions) ([]*MachineFull, *Pagination, error) {
 	u := "machines/full"
 
-	req, err := c.NewRequest(http.MethodGet, u, nil, opts)
+	req, err := c.newRequest("GetMachinesFull", http.MethodGet, u, nil, opts)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -211,7 +272,7 @@ func (c *Client) GetMachinesFull(opts ...*RequestOptions) ([]*MachineFull, *Pagi
 func (c *Client) GetMachinesRunning(opts ...*RequestOptions) ([]*Machine, *Pagination, error) {
 	u := "machines/running"
 
-	req, err := c.NewRequest(http.MethodGet, u, nil, opts)
+	req, err := c.newRequest("GetMachinesRunning", http.MethodGet, u, nil, opts)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -230,7 +291,7 @@ func (c *Client) GetMachinesRunning(opts ...*RequestOptions) ([]*Machine, *Pagin
 func (c *Client) GetMachinesStopped(opts ...*RequestOptions) ([]*Machine, *Pagination, error) {
 	u := "machines/stopped"
 
-	req, err := c.NewRequest(http.MethodGet, u, nil, opts)
+	req, err := c.newRequest("GetMachinesStopped", http.MethodGet, u, nil, opts)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -248,7 +309,7 @@ func (c *Client) GetMachinesStopped(opts ...*RequestOptions) ([]*Machine, *Pagin
 func (c *Client) GetMachine(name string) (*MachineFull, error) {
 	u := fmt.Sprintf("machines/%s", url.PathEscape(name))
 
-	req, err := c.NewRequest(http.MethodGet, u, nil, nil)
+	req, err := c.newRequest("GetMachine", http.MethodGet, u, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -267,7 +328,7 @@ func (c *Client) GetMachine(name string) (*MachineFull, error) {
 func (c *Client) GetMachineJobs(name string, opts ...*RequestOptions) ([]*Job, *Pagination, error) {
 	u := fmt.Sprintf("machines/%s/jobs", url.PathEscape(name))
 
-	req, err := c.NewRequest(http.MethodGet, u, nil, opts)
+	req, err := c.newRequest("GetMachineJobs", http.MethodGet, u, nil, opts)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -286,7 +347,7 @@ func (c *Client) GetMachineJobs(name string, opts ...*RequestOptions) ([]*Job, *
 func (c *Client) GetMachineUsers(name string, opts ...*RequestOptions) ([]*User, *Pagination, error) {
 	u := fmt.Sprintf("machines/%s/users", url.PathEscape(name))
 
-	req, err := c.NewRequest(http.MethodGet, u, nil, opts)
+	req, err := c.newRequest("GetMachineUsers", http.MethodGet, u, nil, opts)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -302,21 +363,27 @@ func (c *Client) GetMachineUsers(name string, opts ...*RequestOptions) ([]*User,
 
 // Change VPS machine password
 func (c *Client) ChangeMachinePassword(name, pass string) (bool, error) {
+	ok, _, err := c.changeMachinePassword(name, pass)
+	return ok, err
+}
+
+// Same as ChangeMachinePassword() but also returns http status code, 0 if no response was received
+func (c *Client) changeMachinePassword(name, pass string) (bool, int, error) {
 	u := fmt.Sprintf("machines/%s/change_password", url.PathEscape(name))
 
-	opt := &password{Password: pass}
-	req, err := c.NewRequest(http.MethodPost, u, opt, nil)
+	opt := &password{Password: Secret(pass)}
+	req, err := c.newRequest("ChangeMachinePassword", http.MethodPost, u, opt, nil)
 	if err != nil {
-		return false, err
+		return false, 0, err
 	}
 
 	result := new(result)
-	_, err = c.Do(req, result)
+	_, status, err := c.do(req, result)
 	if err != nil {
-		return false, err
+		return false, status, err
 	}
 
-	return result.Result, nil
+	return result.Result, status, nil
 }
 
 // helper func to validate command for SendMachineCommand()
@@ -346,7 +413,7 @@ func (c *Client) SendMachineCommand(name, command string) ([]*Job, error) {
 	}
 	u := fmt.Sprintf("machines/%s/%s", url.PathEscape(name), url.PathEscape(command))
 
-	req, err := c.NewRequest(http.MethodPost, u, nil, nil)
+	req, err := c.newRequest("SendMachineCommand", http.MethodPost, u, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -366,7 +433,7 @@ func (c *Client) SendMachineCommand(name, command string) ([]*Job, error) {
 func (c *Client) AddMachineIP(name string) (string, []*Job, error) {
 	u := fmt.Sprintf("machines/%s/add_ip", url.PathEscape(name))
 
-	req, err := c.NewRequest(http.MethodPost, u, nil, nil)
+	req, err := c.newRequest("AddMachineIP", http.MethodPost, u, nil, nil)
 	if err != nil {
 		return "", nil, err
 	}
@@ -386,7 +453,7 @@ func (c *Client) AddMachineIP(name string) (string, []*Job, error) {
 func (c *Client) DeleteMachine(name string) ([]*Job, error) {
 	u := fmt.Sprintf("machines/%s", url.PathEscape(name))
 
-	req, err := c.NewRequest(http.MethodDelete, u, nil, nil)
+	req, err := c.newRequest("DeleteMachine", http.MethodDelete, u, nil, nil)
 	if err != nil {
 		return nil, err
 	}
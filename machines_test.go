@@ -154,11 +154,11 @@ func TestChangeMachinePassword(t *testing.T) {
 	})
 
 	want := true
-	got, err := client.ChangeMachinePassword("VPS0123", "secret")
+	got, err := client.ChangeMachinePassword("VPS0123", "Secret-123")
 	require.NoError(t, err)
 	require.Equal(t, want, got)
 
-	got, err = client.ChangeMachinePassword("VPS01", "secret")
+	got, err = client.ChangeMachinePassword("VPS01", "Secret-123")
 	require.Error(t, err)
 	require.False(t, got)
 }
@@ -253,12 +253,12 @@ func TestUpdateMachine(t *testing.T) {
 
 	mux.HandleFunc(apiVerPath+"machines/VPS0123", func(w http.ResponseWriter, r *http.Request) {
 		require.Equal(t, http.MethodPut, r.Method)
-		require.Equal(t, `{"password":"secret"}`, getBody(t, r))
+		require.Equal(t, `{"password":"Secret-123"}`, getBody(t, r))
 		writeFixture(t, w, "jobspost.json")
 	})
 
 	want := []*Job{{ID: 1, ParentID: 1, MachineID: 123, Type: "Change", Status: "Complete", StartTime: "2020-10-20 01:02:03"}}
-	opts := &UpdateMachineOptions{Password: "secret"}
+	opts := &UpdateMachineOptions{Password: "Secret-123"}
 	got, err := client.UpdateMachine("VPS0123", opts)
 	require.NoError(t, err)
 	require.Equal(t, want, got)
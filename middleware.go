@@ -0,0 +1,108 @@
+package winvps
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Represents a single api round-trip, returns http response along with its raw body
+type RoundTripFunc func(req *http.Request) (*http.Response, []byte, error)
+
+// Represents a function wrapping the round-trip. Middleware is able to inspect and modify
+// the request before sending and the response with its raw body after
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// Add middlewares to api client. Middlewares are called in the passed order,
+// the first one is the outermost
+func Middlewares(mw ...Middleware) Option {
+	return func(c *Client) error {
+		c.middlewares = append(c.middlewares, mw...)
+		return nil
+	}
+}
+
+// Send request through the middleware chain
+func (c *Client) roundTrip(req *http.Request) (*http.Response, []byte, error) {
+	next := c.send
+	if c.dryRun != nil {
+		next = c.dryRun.wrap(next)
+	}
+	if c.logger != nil {
+		next = c.logRoundTrip(next)
+	}
+	if c.retry != nil {
+		next = c.retry.wrap(next)
+	}
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		next = c.middlewares[i](next)
+	}
+	if c.guard != nil {
+		next = c.guard.wrap(c, next)
+	}
+	if c.audit != nil {
+		next = c.audit.wrap(next, c.dryRun != nil, c.logger)
+	}
+	return next(req)
+}
+
+// Send request using http client and read the whole response body
+func (c *Client) send(req *http.Request) (*http.Response, []byte, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp, nil, err
+	}
+	return resp, body, nil
+}
+
+// Logs method, url, status and duration of each request, log.Default() used if l is nil
+func LoggingMiddleware(l *log.Logger) Middleware {
+	if l == nil {
+		l = log.Default()
+	}
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, []byte, error) {
+			start := time.Now()
+			resp, body, err := next(req)
+			d := time.Since(start)
+			if err != nil {
+				l.Printf("%s %s failed after %s: %v", req.Method, req.URL.Path, d, err)
+				return resp, body, err
+			}
+			l.Printf("%s %s %d %s", req.Method, req.URL.Path, resp.StatusCode, d)
+			return resp, body, err
+		}
+	}
+}
+
+// Sets passed headers to each request, existing headers with the same name are replaced
+func HeaderMiddleware(h http.Header) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, []byte, error) {
+			for k, v := range h {
+				req.Header[http.CanonicalHeaderKey(k)] = v
+			}
+			return next(req)
+		}
+	}
+}
+
+// Calls fn with the request, response and duration of each round-trip,
+// resp is nil if the request failed
+func TimingMiddleware(fn func(req *http.Request, resp *http.Response, d time.Duration)) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, []byte, error) {
+			start := time.Now()
+			resp, body, err := next(req)
+			fn(req, resp, time.Since(start))
+			return resp, body, err
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package winvps
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddlewaresOrder(t *testing.T) {
+	mux, server, _ := setup(t)
+	defer teardown(server)
+
+	mux.HandleFunc(apiVerPath+"machines", func(w http.ResponseWriter, r *http.Request) {
+		writeFixture(t, w, "machines.json")
+	})
+
+	var calls []string
+	mw := func(name string) Middleware {
+		return func(next RoundTripFunc) RoundTripFunc {
+			return func(req *http.Request) (*http.Response, []byte, error) {
+				calls = append(calls, name+" before")
+				resp, body, err := next(req)
+				calls = append(calls, name+" after")
+				return resp, body, err
+			}
+		}
+	}
+
+	client, err := NewClient("secret", BaseURL(server.URL), Middlewares(mw("first"), mw("second")))
+	require.NoError(t, err)
+
+	_, _, err = client.GetMachines()
+	require.NoError(t, err)
+	require.Equal(t, []string{"first before", "second before", "second after", "first after"}, calls)
+}
+
+func TestMiddlewareModifyBody(t *testing.T) {
+	mux, server, _ := setup(t)
+	defer teardown(server)
+
+	mux.HandleFunc(apiVerPath+"machines", func(w http.ResponseWriter, r *http.Request) {
+		writeFixture(t, w, "machines.json")
+	})
+
+	rewrite := func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, []byte, error) {
+			resp, body, err := next(req)
+			return resp, bytes.Replace(body, []byte("VPS0123"), []byte("VPS0456"), 1), err
+		}
+	}
+
+	client, err := NewClient("secret", BaseURL(server.URL), Middlewares(rewrite))
+	require.NoError(t, err)
+
+	got, _, err := client.GetMachines()
+	require.NoError(t, err)
+	require.Equal(t, []*Machine{{Name: "VPS0456", Status: "Running"}}, got)
+}
+
+func TestHeaderMiddleware(t *testing.T) {
+	mux, server, _ := setup(t)
+	defer teardown(server)
+
+	mux.HandleFunc(apiVerPath+"machines", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "abc", r.Header.Get("X-Request-Id"))
+		require.Equal(t, "custom", r.Header.Get("User-Agent"))
+		writeFixture(t, w, "machines.json")
+	})
+
+	h := http.Header{}
+	h.Set("X-Request-Id", "abc")
+	h.Set("User-Agent", "custom")
+	client, err := NewClient("secret", BaseURL(server.URL), Middlewares(HeaderMiddleware(h)))
+	require.NoError(t, err)
+
+	_, _, err = client.GetMachines()
+	require.NoError(t, err)
+}
+
+func TestLoggingAndTimingMiddleware(t *testing.T) {
+	mux, server, _ := setup(t)
+	defer teardown(server)
+
+	mux.HandleFunc(apiVerPath+"jobs/1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"not found"}`))
+	})
+
+	buf := new(bytes.Buffer)
+	var status int
+	timing := TimingMiddleware(func(req *http.Request, resp *http.Response, d time.Duration) {
+		status = resp.StatusCode
+	})
+	client, err := NewClient("secret", BaseURL(server.URL), Middlewares(LoggingMiddleware(log.New(buf, "", 0)), timing))
+	require.NoError(t, err)
+
+	_, err = client.GetJob(1)
+	require.EqualError(t, err, "status: 404, error: not found")
+	require.Equal(t, http.StatusNotFound, status)
+	require.True(t, strings.HasPrefix(buf.String(), "GET /api/v2/jobs/1 404 "), buf.String())
+}
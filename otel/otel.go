@@ -0,0 +1,129 @@
+// Package otel provides OpenTelemetry instrumentation of winvps api client as a middleware.
+// It's a separate module, so the client itself doesn't depend on OpenTelemetry.
+package otel
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/fozzyhosting/winvps-go-client"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/fozzyhosting/winvps-go-client/otel"
+
+// Holds OpenTelemetry tracer and instruments used by api client
+type telemetry struct {
+	tracer   trace.Tracer
+	requests metric.Int64Counter
+	errors   metric.Int64Counter
+	duration metric.Float64Histogram
+}
+
+// Returns middleware instrumenting api client with OpenTelemetry. A span named after the client
+// method (e.g. "winvps.CreateMachine") is created per api call and request count, error count and
+// latency metrics are recorded. Global providers are used if tp or mp is nil
+func Middleware(tp trace.TracerProvider, mp metric.MeterProvider) (winvps.Middleware, error) {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+	meter := mp.Meter(instrumentationName)
+
+	t := &telemetry{tracer: tp.Tracer(instrumentationName)}
+	var err error
+	t.requests, err = meter.Int64Counter("winvps.client.requests",
+		metric.WithDescription("Number of api requests"))
+	if err != nil {
+		return nil, err
+	}
+	t.errors, err = meter.Int64Counter("winvps.client.errors",
+		metric.WithDescription("Number of failed api requests"))
+	if err != nil {
+		return nil, err
+	}
+	t.duration, err = meter.Float64Histogram("winvps.client.duration",
+		metric.WithDescription("Duration of api requests"), metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	return t.wrap, nil
+}
+
+// Wrap the round-trip with a span and record metrics
+func (t *telemetry) wrap(next winvps.RoundTripFunc) winvps.RoundTripFunc {
+	return func(req *http.Request) (*http.Response, []byte, error) {
+		op := winvps.OperationFromContext(req.Context())
+		if op == "" {
+			op = req.Method
+		}
+		ctx, span := t.tracer.Start(req.Context(), "winvps."+op,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("winvps.operation", op),
+				attribute.String("http.request.method", req.Method),
+				attribute.String("url.full", req.URL.String()),
+				attribute.String("server.address", req.URL.Hostname()),
+			))
+		defer span.End()
+
+		start := time.Now()
+		resp, body, err := next(req.WithContext(ctx))
+
+		attrs := []attribute.KeyValue{
+			attribute.String("winvps.operation", op),
+			attribute.String("http.request.method", req.Method),
+		}
+		failed := err != nil
+		if resp != nil {
+			attrs = append(attrs, attribute.Int("http.response.status_code", resp.StatusCode))
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+			if resp.StatusCode >= http.StatusBadRequest {
+				failed = true
+			}
+		}
+		if ids := jobIDs(body); len(ids) > 0 {
+			span.SetAttributes(attribute.IntSlice("winvps.job_ids", ids))
+		}
+		switch {
+		case err != nil:
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		case failed:
+			span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+		}
+
+		set := metric.WithAttributes(attrs...)
+		t.requests.Add(ctx, 1, set)
+		t.duration.Record(ctx, time.Since(start).Seconds(), set)
+		if failed {
+			t.errors.Add(ctx, 1, set)
+		}
+
+		return resp, body, err
+	}
+}
+
+// Returns IDs of jobs from response body of mutating calls, nil if there are no jobs
+func jobIDs(body []byte) []int {
+	result := new(struct {
+		Data struct {
+			Jobs []*winvps.Job `json:"jobs"`
+		} `json:"data"`
+	})
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil
+	}
+	var ids []int
+	for _, j := range result.Data.Jobs {
+		ids = append(ids, j.ID)
+	}
+	return ids
+}
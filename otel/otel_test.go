@@ -0,0 +1,76 @@
+package otel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fozzyhosting/winvps-go-client"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestMiddleware(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/api/v2/machines", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"name":"VPS01","jobs":[{"id":1,"status":"Pending"}]}}`)
+	})
+	mux.HandleFunc("/api/v2/jobs/1", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"not found"}`))
+	})
+
+	spans := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	mw, err := Middleware(tp, mp)
+	require.NoError(t, err)
+	client, err := winvps.NewClient("secret", winvps.BaseURL(server.URL), winvps.Middlewares(mw))
+	require.NoError(t, err)
+
+	_, _, err = client.CreateMachine(&winvps.CreateMachineOptions{ProductID: 1, TemplateID: 1, LocationID: 1})
+	require.NoError(t, err)
+	_, err = client.GetJob(1)
+	require.Error(t, err)
+
+	got := spans.GetSpans()
+	require.Len(t, got, 2)
+
+	require.Equal(t, "winvps.CreateMachine", got[0].Name)
+	require.Equal(t, codes.Unset, got[0].Status.Code)
+	require.Contains(t, got[0].Attributes, attribute.IntSlice("winvps.job_ids", []int{1}))
+	require.Contains(t, got[0].Attributes, attribute.String("http.request.method", http.MethodPost))
+	require.Contains(t, got[0].Attributes, attribute.Int("http.response.status_code", http.StatusOK))
+
+	require.Equal(t, "winvps.GetJob", got[1].Name)
+	require.Equal(t, codes.Error, got[1].Status.Code)
+
+	rm := metricdata.ResourceMetrics{}
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	sums := map[string]int64{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		if sum, ok := m.Data.(metricdata.Sum[int64]); ok {
+			for _, dp := range sum.DataPoints {
+				sums[m.Name] += dp.Value
+			}
+		}
+		if hist, ok := m.Data.(metricdata.Histogram[float64]); ok {
+			require.Len(t, hist.DataPoints, 2)
+		}
+	}
+	require.Equal(t, map[string]int64{"winvps.client.requests": 2, "winvps.client.errors": 1}, sums)
+}
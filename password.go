@@ -0,0 +1,132 @@
+package winvps
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+// Password length limits of Windows local accounts
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 127
+)
+
+// Character classes of generated passwords
+const (
+	PasswordLower   = "abcdefghijklmnopqrstuvwxyz"
+	PasswordUpper   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	PasswordDigits  = "0123456789"
+	PasswordSymbols = "!#$%&()*+,-./:;<=>?@[]^_{|}~"
+)
+
+// Represents password generator options, all character classes are used when none is set
+type PasswordOptions struct {
+	// Password length, 16 by default
+	Length  int
+	Lower   bool
+	Upper   bool
+	Digits  bool
+	Symbols bool
+	// Characters never used, e.g. ambiguous "0O1lI"
+	Exclude string
+}
+
+// Generate a crypto-random password with at least one character of each used class.
+// At least 3 classes are required to satisfy Windows complexity requirements
+func GeneratePassword(opt *PasswordOptions) (string, error) {
+	if opt == nil {
+		opt = &PasswordOptions{}
+	}
+	length := opt.Length
+	if length == 0 {
+		length = 16
+	}
+	if length < MinPasswordLength || length > MaxPasswordLength {
+		return "", fmt.Errorf("password length must be between %d and %d but %d passed", MinPasswordLength, MaxPasswordLength, length)
+	}
+
+	classes := []string{}
+	for _, c := range []struct {
+		use   bool
+		chars string
+	}{{opt.Lower, PasswordLower}, {opt.Upper, PasswordUpper}, {opt.Digits, PasswordDigits}, {opt.Symbols, PasswordSymbols}} {
+		if c.use || !(opt.Lower || opt.Upper || opt.Digits || opt.Symbols) {
+			chars := strings.Map(func(r rune) rune {
+				if strings.ContainsRune(opt.Exclude, r) {
+					return -1
+				}
+				return r
+			}, c.chars)
+			if chars != "" {
+				classes = append(classes, chars)
+			}
+		}
+	}
+	if len(classes) < 3 {
+		return "", fmt.Errorf("at least 3 character classes are required but %d available", len(classes))
+	}
+
+	all := strings.Join(classes, "")
+	password := make([]byte, length)
+	for i := range password {
+		chars := all
+		if i < len(classes) {
+			chars = classes[i]
+		}
+		n, err := randInt(len(chars))
+		if err != nil {
+			return "", err
+		}
+		password[i] = chars[n]
+	}
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randInt(i + 1)
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+	return string(password), nil
+}
+
+func randInt(max int) (int, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+	if err != nil {
+		return 0, err
+	}
+	return int(n.Int64()), nil
+}
+
+// Validate password against Windows complexity requirements: length between MinPasswordLength
+// and MaxPasswordLength, characters of at least 3 of lowercase, uppercase, digits and symbols
+// classes, and no Administrator account name
+func ValidatePassword(password string) error {
+	if n := len([]rune(password)); n < MinPasswordLength || n > MaxPasswordLength {
+		return fmt.Errorf("password length must be between %d and %d", MinPasswordLength, MaxPasswordLength)
+	}
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		case unicode.IsControl(r):
+			return fmt.Errorf("password must not contain control characters")
+		default:
+			symbol = 1
+		}
+	}
+	if lower+upper+digit+symbol < 3 {
+		return fmt.Errorf("password must contain characters of at least 3 of lowercase, uppercase, digits and symbols")
+	}
+	if strings.Contains(strings.ToLower(password), "administrator") {
+		return fmt.Errorf("password must not contain account name")
+	}
+	return nil
+}
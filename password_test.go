@@ -0,0 +1,60 @@
+package winvps
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratePassword(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		p, err := GeneratePassword(nil)
+		require.NoError(t, err)
+		require.Len(t, p, 16)
+		require.NoError(t, ValidatePassword(p), p)
+		require.True(t, strings.ContainsAny(p, PasswordSymbols), p)
+	}
+
+	p, err := GeneratePassword(&PasswordOptions{Length: 8, Lower: true, Upper: true, Digits: true, Exclude: "0O1lI"})
+	require.NoError(t, err)
+	require.Len(t, p, 8)
+	require.NoError(t, ValidatePassword(p))
+	require.False(t, strings.ContainsAny(p, PasswordSymbols+"0O1lI"), p)
+
+	_, err = GeneratePassword(&PasswordOptions{Length: 4})
+	require.EqualError(t, err, "password length must be between 8 and 127 but 4 passed")
+	_, err = GeneratePassword(&PasswordOptions{Lower: true, Digits: true})
+	require.EqualError(t, err, "at least 3 character classes are required but 2 available")
+	_, err = GeneratePassword(&PasswordOptions{Lower: true, Upper: true, Digits: true, Exclude: PasswordDigits})
+	require.EqualError(t, err, "at least 3 character classes are required but 2 available")
+}
+
+func TestValidatePassword(t *testing.T) {
+	for _, p := range []string{"Secret-123", "secret-123", "SECRET123x", "Пароль-12"} {
+		require.NoError(t, ValidatePassword(p), p)
+	}
+	for p, msg := range map[string]string{
+		"Se-1":                   "password length must be between 8 and 127",
+		strings.Repeat("a", 128): "password length must be between 8 and 127",
+		"secret123":              "password must contain characters of at least 3 of lowercase, uppercase, digits and symbols",
+		"Secret\t123":            "password must not contain control characters",
+		"MyAdministrator-1":      "password must not contain account name",
+	} {
+		require.EqualError(t, ValidatePassword(p), msg, p)
+	}
+}
+
+func TestValidateOptionsPassword(t *testing.T) {
+	client, err := NewClient("secret")
+	require.NoError(t, err)
+
+	_, err = client.ChangeMachinePassword("VPS0123", "secretsecret")
+	require.EqualError(t, err, "password must contain characters of at least 3 of lowercase, uppercase, digits and symbols")
+	_, err = client.UpdateMachine("VPS0123", &UpdateMachineOptions{Password: "short"})
+	require.Error(t, err)
+	_, err = client.ReinstallMachine("VPS0123", &ReinstallMachineOptions{Password: "short"})
+	require.Error(t, err)
+	_, _, err = client.CreateMachine(&CreateMachineOptions{ProductID: 1, TemplateID: 1, LocationID: 1, Password: "short"})
+	require.Error(t, err)
+}
@@ -25,7 +25,7 @@ type Limits struct {
 func (c *Client) GetProducts(opts ...*RequestOptions) ([]*Product, *Pagination, error) {
 	u := "products"
 
-	req, err := c.NewRequest(http.MethodGet, u, nil, opts)
+	req, err := c.newRequest("GetProducts", http.MethodGet, u, nil, opts)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -38,3 +38,22 @@ func (c *Client) GetProducts(opts ...*RequestOptions) ([]*Product, *Pagination,
 
 	return result, &resp.Pagination, nil
 }
+
+// Returns the product whose limits equal machine config, nil if there is no such product.
+// The api doesn't return product of a machine, so machines with additional resources can't be matched
+func MatchProduct(config *Limits, products []*Product) *Product {
+	if config == nil {
+		return nil
+	}
+	for _, p := range products {
+		l := p.Limits
+		if l == nil {
+			continue
+		}
+		if l.CpuCores == config.CpuCores && l.CpuPercent == config.CpuPercent && l.RamMin == config.RamMin &&
+			l.RamMax == config.RamMax && l.DiskSize == config.DiskSize && l.Bandwidth == config.Bandwidth {
+			return p
+		}
+	}
+	return nil
+}
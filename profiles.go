@@ -0,0 +1,211 @@
+package winvps
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Name of the profile used when no profile is selected
+const DefaultProfile = "default"
+
+// Represents a profiles file, e.g.
+//
+//	default: prod
+//	profiles:
+//	  prod:
+//	    token: secret
+//	    location_id: 1
+//	    timeout: 1m
+//	    retry:
+//	      max_retries: 3
+type Profiles struct {
+	// Profile used when no profile name is passed
+	Default  string              `yaml:"default"`
+	Profiles map[string]*Profile `yaml:"profiles"`
+}
+
+// Represents a single account configuration. The api token is taken from Token,
+// TokenFile or output of TokenCommand, whichever is set first
+type Profile struct {
+	Name         string        `yaml:"-"`
+	Token        string        `yaml:"token"`
+	TokenFile    string        `yaml:"token_file"`
+	TokenCommand []string      `yaml:"token_command"`
+	BaseURL      string        `yaml:"base_url"`
+	LocationID   int           `yaml:"location_id"`
+	ProductID    int           `yaml:"product_id"`
+	TemplateID   int           `yaml:"template_id"`
+	Timeout      time.Duration `yaml:"timeout"`
+	Retry        *RetryPolicy  `yaml:"retry"`
+}
+
+// Returns default profiles file path, e.g. ~/.config/winvps/config.yaml on Linux
+func DefaultProfilesPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "winvps", "config.yaml")
+}
+
+// Read profiles from yaml or json file
+func LoadProfiles(path string) (*Profiles, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := &Profiles{}
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("unable to parse profiles file %s: %v", path, err)
+	}
+	return p, nil
+}
+
+// Returns profile by name with env overrides applied. Profile is selected in order:
+// passed name, WINVPS_PROFILE env, default from the file, "default".
+// Profile fields are overridden by env variables WINVPS_TOKEN, WINVPS_BASE_URL,
+// WINVPS_LOCATION_ID, WINVPS_PRODUCT_ID, WINVPS_TEMPLATE_ID, WINVPS_TIMEOUT and WINVPS_MAX_RETRIES.
+// Nil Profiles is valid and means empty file, so a profile can be built from env only
+func (ps *Profiles) Profile(name string) (*Profile, error) {
+	explicit := true
+	if name == "" {
+		name = os.Getenv("WINVPS_PROFILE")
+	}
+	if name == "" && ps != nil {
+		name = ps.Default
+	}
+	if name == "" {
+		name, explicit = DefaultProfile, false
+	}
+
+	p := &Profile{}
+	if ps != nil && ps.Profiles[name] != nil {
+		*p = *ps.Profiles[name]
+		if p.Retry != nil {
+			retry := *p.Retry
+			p.Retry = &retry
+		}
+	} else if explicit {
+		return nil, fmt.Errorf("profile '%s' not found", name)
+	}
+	p.Name = name
+
+	if err := p.applyEnv(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Override profile fields by env variables
+func (p *Profile) applyEnv() error {
+	if v := os.Getenv("WINVPS_TOKEN"); v != "" {
+		p.Token = v
+	}
+	if v := os.Getenv("WINVPS_BASE_URL"); v != "" {
+		p.BaseURL = v
+	}
+	ints := map[string]*int{
+		"WINVPS_LOCATION_ID": &p.LocationID,
+		"WINVPS_PRODUCT_ID":  &p.ProductID,
+		"WINVPS_TEMPLATE_ID": &p.TemplateID,
+	}
+	for env, field := range ints {
+		if v := os.Getenv(env); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid %s value '%s': %v", env, v, err)
+			}
+			*field = n
+		}
+	}
+	if v := os.Getenv("WINVPS_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid WINVPS_TIMEOUT value '%s': %v", v, err)
+		}
+		p.Timeout = d
+	}
+	if v := os.Getenv("WINVPS_MAX_RETRIES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid WINVPS_MAX_RETRIES value '%s': %v", v, err)
+		}
+		if p.Retry == nil {
+			p.Retry = &RetryPolicy{}
+		}
+		p.Retry.MaxRetries = n
+	}
+	return nil
+}
+
+// Returns client options built from profile
+func (p *Profile) Options() []Option {
+	var opts []Option
+	if p.BaseURL != "" {
+		opts = append(opts, BaseURL(p.BaseURL))
+	}
+	if p.Timeout > 0 {
+		opts = append(opts, Timeout(p.Timeout))
+	}
+	if p.Retry != nil {
+		opts = append(opts, Retry(*p.Retry))
+	}
+	return opts
+}
+
+// Returns token source of profile: static token, token file or token command
+func (p *Profile) TokenSource() (TokenSource, error) {
+	switch {
+	case p.Token != "":
+		return StaticToken(p.Token), nil
+	case p.TokenFile != "":
+		return FileToken(p.TokenFile), nil
+	case len(p.TokenCommand) > 0:
+		return CommandToken(time.Minute, p.TokenCommand[0], p.TokenCommand[1:]...), nil
+	}
+	return nil, fmt.Errorf("profile '%s' has no token", p.Name)
+}
+
+// Creates a new api client from profile, passed options are applied after profile ones
+func (p *Profile) NewClient(opts ...Option) (*Client, error) {
+	ts, err := p.TokenSource()
+	if err != nil {
+		return nil, err
+	}
+	return NewClient("", append([]Option{Credentials(ts)}, append(p.Options(), opts...)...)...)
+}
+
+// Fill empty ProductID, TemplateID and LocationID of CreateMachineOptions with profile defaults
+func (p *Profile) ApplyDefaults(opt *CreateMachineOptions) {
+	if opt.ProductID == 0 {
+		opt.ProductID = p.ProductID
+	}
+	if opt.TemplateID == 0 {
+		opt.TemplateID = p.TemplateID
+	}
+	if opt.LocationID == 0 {
+		opt.LocationID = p.LocationID
+	}
+}
+
+// Creates a new api client from named profile of profiles file. Empty path means
+// DefaultProfilesPath(), see Profiles.Profile() for profile selection and env overrides
+func NewClientFromProfile(path, name string, opts ...Option) (*Client, error) {
+	if path == "" {
+		path = DefaultProfilesPath()
+	}
+	ps, err := LoadProfiles(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := ps.Profile(name)
+	if err != nil {
+		return nil, err
+	}
+	return p.NewClient(opts...)
+}
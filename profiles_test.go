@@ -0,0 +1,118 @@
+package winvps
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const profilesFile = `
+default: prod
+profiles:
+  prod:
+    token: prod-token
+    location_id: 1
+    product_id: 2
+    template_id: 3
+    timeout: 1m
+    retry:
+      max_retries: 3
+      wait: 2s
+  staging:
+    token: staging-token
+    base_url: https://staging.example.com
+`
+
+func writeProfiles(t *testing.T) string {
+	for _, env := range []string{"WINVPS_PROFILE", "WINVPS_TOKEN", "WINVPS_BASE_URL", "WINVPS_LOCATION_ID",
+		"WINVPS_PRODUCT_ID", "WINVPS_TEMPLATE_ID", "WINVPS_TIMEOUT", "WINVPS_MAX_RETRIES"} {
+		t.Setenv(env, "")
+	}
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(profilesFile), 0600))
+	return path
+}
+
+func TestProfiles(t *testing.T) {
+	ps, err := LoadProfiles(writeProfiles(t))
+	require.NoError(t, err)
+
+	got, err := ps.Profile("")
+	require.NoError(t, err)
+	want := &Profile{Name: "prod", Token: "prod-token", LocationID: 1, ProductID: 2, TemplateID: 3,
+		Timeout: time.Minute, Retry: &RetryPolicy{MaxRetries: 3, Wait: 2 * time.Second}}
+	require.Equal(t, want, got)
+
+	t.Setenv("WINVPS_PROFILE", "staging")
+	got, err = ps.Profile("")
+	require.NoError(t, err)
+	require.Equal(t, "staging-token", got.Token)
+
+	got, err = ps.Profile("prod")
+	require.NoError(t, err)
+	require.Equal(t, "prod", got.Name)
+
+	_, err = ps.Profile("missing")
+	require.EqualError(t, err, "profile 'missing' not found")
+}
+
+func TestProfileEnvOverrides(t *testing.T) {
+	ps, err := LoadProfiles(writeProfiles(t))
+	require.NoError(t, err)
+
+	t.Setenv("WINVPS_TOKEN", "env-token")
+	t.Setenv("WINVPS_LOCATION_ID", "5")
+	t.Setenv("WINVPS_TIMEOUT", "10s")
+	t.Setenv("WINVPS_MAX_RETRIES", "0")
+	got, err := ps.Profile("")
+	require.NoError(t, err)
+	require.Equal(t, "env-token", got.Token)
+	require.Equal(t, 5, got.LocationID)
+	require.Equal(t, 10*time.Second, got.Timeout)
+	require.Equal(t, 0, got.Retry.MaxRetries)
+	require.Equal(t, 3, ps.Profiles["prod"].Retry.MaxRetries)
+
+	t.Setenv("WINVPS_PRODUCT_ID", "abc")
+	_, err = ps.Profile("")
+	require.EqualError(t, err, `invalid WINVPS_PRODUCT_ID value 'abc': strconv.Atoi: parsing "abc": invalid syntax`)
+
+	// profile can be built from env only
+	var empty *Profiles
+	got, err = empty.Profile("")
+	require.Error(t, err)
+	t.Setenv("WINVPS_PRODUCT_ID", "")
+	got, err = empty.Profile("")
+	require.NoError(t, err)
+	require.Equal(t, "env-token", got.Token)
+}
+
+func TestProfileDefaults(t *testing.T) {
+	p := &Profile{LocationID: 1, ProductID: 2, TemplateID: 3}
+	opt := &CreateMachineOptions{TemplateID: 4}
+	p.ApplyDefaults(opt)
+	require.Equal(t, &CreateMachineOptions{LocationID: 1, ProductID: 2, TemplateID: 4}, opt)
+}
+
+func TestNewClientFromProfile(t *testing.T) {
+	mux, server, _ := setup(t)
+	defer teardown(server)
+
+	path := writeProfiles(t)
+	mux.HandleFunc(apiVerPath+"brands", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "prod-token", r.Header.Get("API-KEY"))
+		writeFixture(t, w, "brands.json")
+	})
+
+	t.Setenv("WINVPS_BASE_URL", server.URL)
+	client, err := NewClientFromProfile(path, "")
+	require.NoError(t, err)
+	require.Equal(t, time.Minute, client.httpClient.Timeout)
+	require.Equal(t, &RetryPolicy{MaxRetries: 3, Wait: 2 * time.Second, MaxWait: 30 * time.Second}, client.retry)
+
+	_, _, err = client.GetBrands()
+	require.NoError(t, err)
+}
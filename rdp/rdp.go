@@ -0,0 +1,242 @@
+// Package rdp generates remote desktop connection files for winvps machines.
+//
+// Microsoft .rdp files and Remmina profiles are supported. Passwords are never written,
+// .rdp files require them to be encrypted for the current Windows user, so clients
+// prompt for credentials on connect.
+package rdp
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/fozzyhosting/winvps-go-client"
+)
+
+// Default RDP port
+const DefaultPort = 3389
+
+// Username used when machine has no additional users
+const DefaultUsername = "Administrator"
+
+// Represents connection options, zero values mean client defaults
+type Options struct {
+	// Username, first admin user of the machine by default, see Username()
+	Username string
+	// RDP port, 3389 by default
+	Port int
+	// Window size, full screen when not set
+	Width  int
+	Height int
+	// Use all monitors
+	MultiMonitor bool
+	// Share clipboard with the machine
+	Clipboard bool
+	// Share local drives with the machine
+	Drives bool
+	// Share local printers with the machine
+	Printers bool
+	// Write Remmina profiles along with .rdp files in WriteDir()
+	Remmina bool
+}
+
+// Represents connection to a single machine
+type Connection struct {
+	Machine  string `json:"machine"`
+	Address  string `json:"address"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+}
+
+// Returns connection to the machine using its primary IPv4 address. Machine users are
+// fetched unless Username option is set
+func Connect(c *winvps.Client, m *winvps.MachineFull, opt *Options) (*Connection, error) {
+	if opt == nil {
+		opt = &Options{}
+	}
+	address := PrimaryIPv4(m)
+	if address == "" {
+		return nil, fmt.Errorf("machine %s has no IPv4 address", m.Name)
+	}
+	port := opt.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+	username := opt.Username
+	if username == "" {
+		users, err := winvps.ListAll(func(opts ...*winvps.RequestOptions) ([]*winvps.User, *winvps.Pagination, error) {
+			return c.GetMachineUsers(m.Name, opts...)
+		})
+		if err != nil {
+			return nil, err
+		}
+		username = Username(users)
+	}
+	return &Connection{Machine: m.Name, Address: address, Port: port, Username: username}, nil
+}
+
+// Returns the first IPv4 address of the machine, empty if there is none
+func PrimaryIPv4(m *winvps.MachineFull) string {
+	for _, ip := range m.IPs {
+		if ip.Version == 4 {
+			return ip.Address
+		}
+	}
+	return ""
+}
+
+// Returns username of the first admin user, the first user if there are no admins,
+// DefaultUsername if there are no users
+func Username(users []*winvps.User) string {
+	for _, u := range users {
+		if strings.EqualFold(u.Role, "admin") {
+			return u.Username
+		}
+	}
+	if len(users) > 0 {
+		return users[0].Username
+	}
+	return DefaultUsername
+}
+
+// Write Microsoft .rdp file
+func (conn *Connection) WriteRDP(w io.Writer, opt *Options) error {
+	if opt == nil {
+		opt = &Options{}
+	}
+	lines := []string{
+		"full address:s:" + conn.address(),
+		"username:s:" + conn.Username,
+	}
+	if opt.Width > 0 && opt.Height > 0 {
+		lines = append(lines, "screen mode id:i:1", "desktopwidth:i:"+strconv.Itoa(opt.Width),
+			"desktopheight:i:"+strconv.Itoa(opt.Height))
+	} else {
+		lines = append(lines, "screen mode id:i:2")
+	}
+	drives := ""
+	if opt.Drives {
+		drives = "*"
+	}
+	lines = append(lines,
+		"session bpp:i:32",
+		"use multimon:i:"+boolInt(opt.MultiMonitor),
+		"redirectclipboard:i:"+boolInt(opt.Clipboard),
+		"redirectprinters:i:"+boolInt(opt.Printers),
+		"drivestoredirect:s:"+drives,
+		"prompt for credentials:i:1",
+	)
+	for _, line := range lines {
+		if _, err := io.WriteString(w, line+"\r\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write Remmina profile, local drives aren't shared as Remmina shares a single folder only
+func (conn *Connection) WriteRemmina(w io.Writer, opt *Options) error {
+	if opt == nil {
+		opt = &Options{}
+	}
+	lines := []string{
+		"[remmina]",
+		"name=" + conn.Machine,
+		"protocol=RDP",
+		"server=" + conn.address(),
+		"username=" + conn.Username,
+		"colordepth=32",
+	}
+	if opt.Width > 0 && opt.Height > 0 {
+		lines = append(lines, "resolution_mode=3", "resolution_width="+strconv.Itoa(opt.Width),
+			"resolution_height="+strconv.Itoa(opt.Height), "viewmode=1")
+	} else {
+		lines = append(lines, "resolution_mode=2", "viewmode=4")
+	}
+	lines = append(lines,
+		"multimon="+boolInt(opt.MultiMonitor),
+		"disableclipboard="+boolInt(!opt.Clipboard),
+		"shareprinter="+boolInt(opt.Printers),
+	)
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// Represents files written by WriteDir() for a single machine
+type Export struct {
+	Machine string   `json:"machine"`
+	Paths   []string `json:"paths"`
+	Error   string   `json:"error,omitempty"`
+	Err     error    `json:"-"`
+}
+
+// Write NAME.rdp file for each machine to dir, and NAME.remmina if Remmina option is set.
+// Returns exports in order of passed machines, a machine which can't be exported, e.g. without
+// IPv4 address, is skipped and its export holds the error. Error is returned if dir can't be created
+func WriteDir(c *winvps.Client, machines []*winvps.MachineFull, dir string, opt *Options) ([]*Export, error) {
+	if opt == nil {
+		opt = &Options{}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	exports := make([]*Export, 0, len(machines))
+	for _, m := range machines {
+		e := &Export{Machine: m.Name}
+		e.Paths, e.Err = writeMachine(c, m, dir, opt)
+		if e.Err != nil {
+			e.Error = e.Err.Error()
+		}
+		exports = append(exports, e)
+	}
+	return exports, nil
+}
+
+// Write files of a single machine, returns paths of written files
+func writeMachine(c *winvps.Client, m *winvps.MachineFull, dir string, opt *Options) ([]string, error) {
+	conn, err := Connect(c, m, opt)
+	if err != nil {
+		return nil, err
+	}
+	base := filepath.Join(dir, filepath.Base(m.Name))
+	if err := writeFile(base+".rdp", conn.WriteRDP, opt); err != nil {
+		return nil, err
+	}
+	paths := []string{base + ".rdp"}
+	if opt.Remmina {
+		if err := writeFile(base+".remmina", conn.WriteRemmina, opt); err != nil {
+			return paths, err
+		}
+		paths = append(paths, base+".remmina")
+	}
+	return paths, nil
+}
+
+func writeFile(path string, write func(io.Writer, *Options) error, opt *Options) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := write(f, opt); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (conn *Connection) address() string {
+	if conn.Port == 0 || conn.Port == DefaultPort {
+		return conn.Address
+	}
+	return conn.Address + ":" + strconv.Itoa(conn.Port)
+}
+
+func boolInt(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
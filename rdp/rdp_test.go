@@ -0,0 +1,94 @@
+package rdp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fozzyhosting/winvps-go-client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRDP(t *testing.T) {
+	conn := &Connection{Machine: "VPS01", Address: "10.0.0.1", Port: 3390, Username: "admin"}
+
+	b := new(strings.Builder)
+	require.NoError(t, conn.WriteRDP(b, &Options{Width: 1280, Height: 720, Clipboard: true, Drives: true}))
+	require.Equal(t, "full address:s:10.0.0.1:3390\r\n"+
+		"username:s:admin\r\n"+
+		"screen mode id:i:1\r\n"+
+		"desktopwidth:i:1280\r\n"+
+		"desktopheight:i:720\r\n"+
+		"session bpp:i:32\r\n"+
+		"use multimon:i:0\r\n"+
+		"redirectclipboard:i:1\r\n"+
+		"redirectprinters:i:0\r\n"+
+		"drivestoredirect:s:*\r\n"+
+		"prompt for credentials:i:1\r\n", b.String())
+
+	b.Reset()
+	require.NoError(t, conn.WriteRemmina(b, nil))
+	require.Equal(t, `[remmina]
+name=VPS01
+protocol=RDP
+server=10.0.0.1:3390
+username=admin
+colordepth=32
+resolution_mode=2
+viewmode=4
+multimon=0
+disableclipboard=1
+shareprinter=0
+`, b.String())
+}
+
+func TestUsername(t *testing.T) {
+	require.Equal(t, DefaultUsername, Username(nil))
+	require.Equal(t, "user", Username([]*winvps.User{{Username: "user", Role: "user"}}))
+	require.Equal(t, "admin", Username([]*winvps.User{{Username: "user", Role: "user"}, {Username: "admin", Role: "Admin"}}))
+}
+
+func TestWriteDir(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/api/v2/machines/VPS01/users", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"username":"admin","role":"admin","password":"secret"}],"pagination":{"total":1,"limit":50,"page":1,"pages":1}}`)
+	})
+
+	client, err := winvps.NewClient("secret", winvps.BaseURL(server.URL))
+	require.NoError(t, err)
+
+	machines := []*winvps.MachineFull{{
+		Machine: &winvps.Machine{Name: "VPS01"},
+		IPs:     []*winvps.IP{{Version: 6, Address: "2001:db8::1"}, {Version: 4, Address: "10.0.0.1"}},
+	}}
+	dir := filepath.Join(t.TempDir(), "rdp")
+	exports, err := WriteDir(client, machines, dir, &Options{Remmina: true})
+	require.NoError(t, err)
+	require.Len(t, exports, 1)
+	paths := exports[0].Paths
+	require.Equal(t, []string{filepath.Join(dir, "VPS01.rdp"), filepath.Join(dir, "VPS01.remmina")}, paths)
+
+	data, err := os.ReadFile(paths[0])
+	require.NoError(t, err)
+	require.Contains(t, string(data), "full address:s:10.0.0.1\r\nusername:s:admin\r\n")
+	require.NotContains(t, string(data), "secret")
+
+	// machine without IPv4 address doesn't stop the export
+	machines = []*winvps.MachineFull{{Machine: &winvps.Machine{Name: "VPS02"}}, machines[0]}
+	exports, err = WriteDir(client, machines, dir, &Options{Username: "Administrator"})
+	require.NoError(t, err)
+	require.Len(t, exports, 2)
+	require.Equal(t, "VPS02", exports[0].Machine)
+	require.Empty(t, exports[0].Paths)
+	require.EqualError(t, exports[0].Err, "machine VPS02 has no IPv4 address")
+	require.Equal(t, "machine VPS02 has no IPv4 address", exports[0].Error)
+	require.Equal(t, []string{filepath.Join(dir, "VPS01.rdp")}, exports[1].Paths)
+	require.NoError(t, exports[1].Err)
+}
@@ -0,0 +1,216 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Represents json object which keeps keys order
+type object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newObject() *object {
+	return &object{values: map[string]interface{}{}}
+}
+
+func (o *object) set(key string, v interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = v
+}
+
+// Returns value by dotted path, values of all list items are collected for a path through a list
+func (o *object) get(path string) interface{} {
+	v, _ := lookup(o, path)
+	return v
+}
+
+// Reports whether dotted path exists
+func (o *object) has(path string) bool {
+	_, ok := lookup(o, path)
+	return ok
+}
+
+func lookup(v interface{}, path string) (interface{}, bool) {
+	switch t := v.(type) {
+	case *object:
+		if val, ok := t.values[path]; ok {
+			return val, true
+		}
+		key, rest, found := strings.Cut(path, ".")
+		val, ok := t.values[key]
+		if !ok || !found {
+			return nil, false
+		}
+		return lookup(val, rest)
+	case []interface{}:
+		var values []interface{}
+		found := len(t) == 0
+		for _, item := range t {
+			if val, ok := lookup(item, path); ok {
+				values = append(values, val)
+				found = true
+			}
+		}
+		return values, found
+	}
+	return nil, false
+}
+
+// Returns dotted paths of all leaf values, nested objects are walked and lists are leaves
+func (o *object) paths(prefix string) []string {
+	var paths []string
+	for _, k := range o.keys {
+		if nested, ok := o.values[k].(*object); ok {
+			paths = append(paths, nested.paths(prefix+k+".")...)
+			continue
+		}
+		paths = append(paths, prefix+k)
+	}
+	return paths
+}
+
+// MarshalJSON implements json.Marshaler keeping keys order
+func (o *object) MarshalJSON() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Convert v to object through json, values which are not json objects are stored under "value" key
+func toObject(v interface{}) (*object, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	decoded, err := decodeValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if o, ok := decoded.(*object); ok {
+		return o, nil
+	}
+	o := newObject()
+	o.set("value", decoded)
+	return o, nil
+}
+
+// Decode next json value keeping objects keys order
+func decodeValue(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		o := newObject()
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			o.set(key.(string), v)
+		}
+		_, err := dec.Token()
+		return o, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		_, err := dec.Token()
+		return list, err
+	}
+	return tok, nil
+}
+
+// Format value as a single table cell, lists of scalars are joined with commas
+// and other nested values are rendered as compact json
+func format(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case []interface{}:
+		values := make([]string, len(t))
+		for i, item := range t {
+			switch item.(type) {
+			case *object, []interface{}:
+				data, _ := json.Marshal(t)
+				return string(data)
+			}
+			values[i] = format(item)
+		}
+		return strings.Join(values, ",")
+	case *object:
+		data, _ := json.Marshal(t)
+		return string(data)
+	}
+	return fmt.Sprint(v)
+}
+
+// Convert decoded json value to yaml node keeping objects keys order
+func toYAML(v interface{}) *yaml.Node {
+	switch t := v.(type) {
+	case *object:
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for _, k := range t.keys {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: k}, toYAML(t.values[k]))
+		}
+		return node
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range t {
+			node.Content = append(node.Content, toYAML(item))
+		}
+		return node
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: t}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(t)}
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(string(t), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: string(t)}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: fmt.Sprint(v)}
+}
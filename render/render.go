@@ -0,0 +1,259 @@
+// Package render formats winvps api results as table, json, ndjson, yaml, csv or go template.
+//
+// Any value which can be marshalled to json is supported, a slice is rendered as a list of rows
+// and a single value as a single row. Columns are named after json keys, nested fields are
+// addressed with dots, e.g. "config.cpu_cores", and a path through a list collects values of
+// all its items, e.g. "ips.address".
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Available formats
+const (
+	Table    = "table"
+	JSON     = "json"
+	NDJSON   = "ndjson"
+	YAML     = "yaml"
+	CSV      = "csv"
+	Template = "template"
+)
+
+// Formats lists all available formats
+var Formats = []string{Table, JSON, NDJSON, YAML, CSV, Template}
+
+// Represents rendering options
+type Options struct {
+	// Output format, Table by default
+	Format string
+	// Columns to render, all columns by default. Ignored by Template format
+	Columns []string
+	// Column to sort rows by, prefixed with "-" for descending order
+	SortBy string
+	// Go template executed for each row, used with Template format
+	Template string
+}
+
+// Render v to w according to the options, nil options means table with all columns
+func Render(w io.Writer, v interface{}, opt *Options) error {
+	if opt == nil {
+		opt = &Options{}
+	}
+	items, single := toItems(v)
+
+	rows := make([]*object, len(items))
+	for i, item := range items {
+		row, err := toObject(item)
+		if err != nil {
+			return err
+		}
+		rows[i] = row
+	}
+	if opt.SortBy != "" {
+		if err := sortRows(items, rows, opt.SortBy); err != nil {
+			return err
+		}
+	}
+
+	columns := opt.Columns
+	if len(columns) == 0 && len(rows) > 0 {
+		columns = rows[0].paths("")
+	}
+
+	switch opt.Format {
+	case "", Table:
+		return renderTable(w, rows, columns)
+	case CSV:
+		return renderCSV(w, rows, columns)
+	case JSON:
+		return renderJSON(w, selectColumns(rows, opt.Columns), single)
+	case NDJSON:
+		return renderNDJSON(w, selectColumns(rows, opt.Columns))
+	case YAML:
+		return renderYAML(w, selectColumns(rows, opt.Columns), single)
+	case Template:
+		return renderTemplate(w, items, opt.Template)
+	}
+	return fmt.Errorf("unknown format '%s', available formats: %s", opt.Format, strings.Join(Formats, ", "))
+}
+
+// Split v into list items, reports whether v is a single value rather than a list
+func toItems(v interface{}) ([]interface{}, bool) {
+	r := reflect.ValueOf(v)
+	if r.Kind() != reflect.Slice && r.Kind() != reflect.Array {
+		return []interface{}{v}, true
+	}
+	items := make([]interface{}, r.Len())
+	for i := range items {
+		items[i] = r.Index(i).Interface()
+	}
+	return items, false
+}
+
+// Sort items and rows in place by column, prefix "-" means descending order
+func sortRows(items []interface{}, rows []*object, by string) error {
+	desc := strings.HasPrefix(by, "-")
+	by = strings.TrimPrefix(by, "-")
+	if len(rows) > 0 && !rows[0].has(by) {
+		return fmt.Errorf("unknown sort column '%s'", by)
+	}
+
+	idx := make([]int, len(rows))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		a, b := rows[idx[i]].get(by), rows[idx[j]].get(by)
+		if desc {
+			a, b = b, a
+		}
+		return less(a, b)
+	})
+
+	sortedItems := make([]interface{}, len(items))
+	sortedRows := make([]*object, len(rows))
+	for i, k := range idx {
+		sortedItems[i], sortedRows[i] = items[k], rows[k]
+	}
+	copy(items, sortedItems)
+	copy(rows, sortedRows)
+	return nil
+}
+
+// Compare values numerically if both are numbers, otherwise as strings
+func less(a, b interface{}) bool {
+	as, bs := format(a), format(b)
+	af, aErr := strconv.ParseFloat(as, 64)
+	bf, bErr := strconv.ParseFloat(bs, 64)
+	if aErr == nil && bErr == nil {
+		return af < bf
+	}
+	return as < bs
+}
+
+// Returns rows with only passed columns, rows are returned as is if no columns passed
+func selectColumns(rows []*object, columns []string) []*object {
+	if len(columns) == 0 {
+		return rows
+	}
+	selected := make([]*object, len(rows))
+	for i, row := range rows {
+		o := newObject()
+		for _, c := range columns {
+			o.set(c, row.get(c))
+		}
+		selected[i] = o
+	}
+	return selected
+}
+
+func renderTable(w io.Writer, rows []*object, columns []string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := make([]string, len(columns))
+	for i, c := range columns {
+		header[i] = strings.ToUpper(c)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		values := make([]string, len(columns))
+		for i, c := range columns {
+			values[i] = format(row.get(c))
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	return tw.Flush()
+}
+
+func renderCSV(w io.Writer, rows []*object, columns []string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		values := make([]string, len(columns))
+		for i, c := range columns {
+			values[i] = format(row.get(c))
+		}
+		if err := cw.Write(values); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func renderJSON(w io.Writer, rows []*object, single bool) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if single && len(rows) == 1 {
+		return enc.Encode(rows[0])
+	}
+	if rows == nil {
+		rows = []*object{}
+	}
+	return enc.Encode(rows)
+}
+
+func renderNDJSON(w io.Writer, rows []*object) error {
+	enc := json.NewEncoder(w)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderYAML(w io.Writer, rows []*object, single bool) error {
+	var node *yaml.Node
+	if single && len(rows) == 1 {
+		node = toYAML(rows[0])
+	} else {
+		node = &yaml.Node{Kind: yaml.SequenceNode}
+		for _, row := range rows {
+			node.Content = append(node.Content, toYAML(row))
+		}
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func renderTemplate(w io.Writer, items []interface{}, text string) error {
+	if text == "" {
+		return fmt.Errorf("template is required for '%s' format", Template)
+	}
+	tmpl, err := template.New("render").Parse(text)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		buf := new(bytes.Buffer)
+		if err := tmpl.Execute(buf, item); err != nil {
+			return err
+		}
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
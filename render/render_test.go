@@ -0,0 +1,87 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fozzyhosting/winvps-go-client"
+	"github.com/stretchr/testify/require"
+)
+
+var machines = []*winvps.MachineFull{
+	{
+		Machine: &winvps.Machine{Name: "VPS02", Status: "Stopped"},
+		IPs:     []*winvps.IP{{Version: 4, Address: "127.0.0.2"}, {Version: 6, Address: "::2"}},
+		Config:  &winvps.Limits{CpuCores: 2, RamMax: 2048},
+	},
+	{
+		Machine: &winvps.Machine{Name: "VPS01", Status: "Running", Notes: "web, db"},
+		IPs:     []*winvps.IP{{Version: 4, Address: "127.0.0.1"}},
+		Config:  &winvps.Limits{CpuCores: 10, RamMax: 1024},
+	},
+}
+
+func render(t *testing.T, v interface{}, opt *Options) string {
+	buf := new(bytes.Buffer)
+	require.NoError(t, Render(buf, v, opt))
+	return buf.String()
+}
+
+func TestTable(t *testing.T) {
+	got := render(t, machines, &Options{Columns: []string{"name", "status", "ips.address", "config.cpu_cores"}, SortBy: "config.cpu_cores"})
+	want := "" +
+		"NAME   STATUS   IPS.ADDRESS    CONFIG.CPU_CORES\n" +
+		"VPS02  Stopped  127.0.0.2,::2  2\n" +
+		"VPS01  Running  127.0.0.1      10\n"
+	require.Equal(t, want, got)
+
+	got = render(t, []*winvps.Brand{{ID: 2, Name: "b"}, {ID: 1, Name: "a"}}, &Options{SortBy: "-name"})
+	require.Equal(t, "ID  NAME\n2   b\n1   a\n", got)
+}
+
+func TestCSV(t *testing.T) {
+	got := render(t, machines, &Options{Format: CSV, Columns: []string{"name", "notes"}, SortBy: "name"})
+	require.Equal(t, "name,notes\nVPS01,\"web, db\"\nVPS02,\n", got)
+
+	got = render(t, []*winvps.Job{{ID: 1, Type: "Change", Status: "Complete"}}, &Options{Format: CSV})
+	require.Equal(t, "id,parent_id,machine_id,type,status,start_time\n1,0,0,Change,Complete,\n", got)
+}
+
+func TestJSON(t *testing.T) {
+	got := render(t, machines[1], &Options{Format: JSON, Columns: []string{"name", "config.ram_max"}})
+	require.JSONEq(t, `{"name":"VPS01","config.ram_max":1024}`, got)
+
+	got = render(t, []*winvps.Template{{ID: 1, Name: "win"}}, &Options{Format: JSON})
+	require.JSONEq(t, `[{"id":1,"name":"win"}]`, got)
+
+	got = render(t, []*winvps.Location{}, &Options{Format: JSON})
+	require.JSONEq(t, `[]`, got)
+}
+
+func TestNDJSON(t *testing.T) {
+	got := render(t, []*winvps.User{{Username: "admin", Role: "admin"}, {Username: "user", Role: "user"}},
+		&Options{Format: NDJSON, Columns: []string{"username"}})
+	require.Equal(t, "{\"username\":\"admin\"}\n{\"username\":\"user\"}\n", got)
+}
+
+func TestYAML(t *testing.T) {
+	got := render(t, []*winvps.Product{{ID: 1, Name: "start", Limits: &winvps.Limits{CpuCores: 1}}},
+		&Options{Format: YAML, Columns: []string{"name", "limits.cpu_cores"}})
+	require.Equal(t, "- name: start\n  limits.cpu_cores: 1\n", got)
+
+	got = render(t, &winvps.Brand{ID: 1, Name: "a"}, &Options{Format: YAML})
+	require.Equal(t, "id: 1\nname: a\n", got)
+}
+
+func TestTemplate(t *testing.T) {
+	got := render(t, machines, &Options{Format: Template, Template: "{{.Name}} {{.Config.CpuCores}}", SortBy: "name"})
+	require.Equal(t, "VPS01 10\nVPS02 2\n", got)
+}
+
+func TestErrors(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.EqualError(t, Render(buf, machines, &Options{Format: "xml"}),
+		"unknown format 'xml', available formats: table, json, ndjson, yaml, csv, template")
+	require.EqualError(t, Render(buf, machines, &Options{SortBy: "size"}), "unknown sort column 'size'")
+	require.EqualError(t, Render(buf, machines, &Options{Format: Template}), "template is required for 'template' format")
+}
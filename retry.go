@@ -0,0 +1,103 @@
+package winvps
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Represents retry policy of api client. GET requests are retried on network errors,
+// 429 and 5xx statuses, other requests are retried on 429 status only, so that
+// mutating calls are never repeated after the server could process them
+type RetryPolicy struct {
+	// Maximum number of retries, 0 disables retries
+	MaxRetries int `yaml:"max_retries"`
+	// Wait before the first retry, doubled for every next one, 1s by default
+	Wait time.Duration `yaml:"wait"`
+	// Maximum wait between retries, 30s by default
+	MaxWait time.Duration `yaml:"max_wait"`
+}
+
+// Set retry policy for api client
+func Retry(p RetryPolicy) Option {
+	return func(c *Client) error {
+		if p.Wait == 0 {
+			p.Wait = time.Second
+		}
+		if p.MaxWait == 0 {
+			p.MaxWait = 30 * time.Second
+		}
+		c.retry = &p
+		return nil
+	}
+}
+
+// Set timeout of a single http request
+func Timeout(d time.Duration) Option {
+	return func(c *Client) error {
+		c.httpClient.Timeout = d
+		return nil
+	}
+}
+
+// Wrap the round-trip with retries, attempt number is stored in the request context
+func (p *RetryPolicy) wrap(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, []byte, error) {
+		for attempt := 0; ; attempt++ {
+			r := req
+			if attempt > 0 {
+				r = req.WithContext(context.WithValue(req.Context(), retryKey{}, attempt))
+				if req.GetBody != nil {
+					body, err := req.GetBody()
+					if err != nil {
+						return nil, nil, err
+					}
+					r.Body = body
+				}
+			}
+			resp, body, err := next(r)
+			if attempt >= p.MaxRetries || !p.retryable(req, resp, err) {
+				return resp, body, err
+			}
+
+			wait := p.backoff(attempt, resp)
+			select {
+			case <-time.After(wait):
+			case <-req.Context().Done():
+				return resp, body, err
+			}
+		}
+	}
+}
+
+// Reports whether request should be retried
+func (p *RetryPolicy) retryable(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		return req.Method == http.MethodGet
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return req.Method == http.MethodGet && resp.StatusCode >= http.StatusInternalServerError
+}
+
+// Returns wait before next attempt, Retry-After header in seconds is honored
+func (p *RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s >= 0 {
+			if d := time.Duration(s) * time.Second; d < p.MaxWait {
+				return d
+			}
+			return p.MaxWait
+		}
+	}
+	wait := p.Wait
+	for i := 0; i < attempt && wait < p.MaxWait; i++ {
+		wait *= 2
+	}
+	if wait > p.MaxWait {
+		return p.MaxWait
+	}
+	return wait
+}
@@ -0,0 +1,73 @@
+package winvps
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetry(t *testing.T) {
+	mux, server, _ := setup(t)
+	defer teardown(server)
+
+	calls := 0
+	mux.HandleFunc(apiVerPath+"machines", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writeFixture(t, w, "machines.json")
+	})
+
+	buf := new(bytes.Buffer)
+	client, err := NewClient("secret", BaseURL(server.URL), Retry(RetryPolicy{MaxRetries: 2, Wait: time.Millisecond}),
+		Logger(slog.New(slog.NewTextHandler(buf, nil))))
+	require.NoError(t, err)
+
+	_, _, err = client.GetMachines()
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+	require.Equal(t, 3, strings.Count(buf.String(), "winvps request"))
+	require.Contains(t, buf.String(), "retry=2 status=200")
+}
+
+func TestRetryMutatingCalls(t *testing.T) {
+	mux, server, _ := setup(t)
+	defer teardown(server)
+
+	calls := 0
+	mux.HandleFunc(apiVerPath+"machines/VPS0123/change_password", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		require.JSONEq(t, `{"password":"Secret-123"}`, getBody(t, r))
+		switch calls {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+
+	client, err := NewClient("secret", BaseURL(server.URL), Retry(RetryPolicy{MaxRetries: 5, Wait: time.Millisecond}))
+	require.NoError(t, err)
+
+	_, err = client.ChangeMachinePassword("VPS0123", "Secret-123")
+	require.Error(t, err)
+	require.Equal(t, 2, calls)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := &RetryPolicy{Wait: time.Second, MaxWait: 5 * time.Second}
+	require.Equal(t, time.Second, p.backoff(0, nil))
+	require.Equal(t, 4*time.Second, p.backoff(2, nil))
+	require.Equal(t, 5*time.Second, p.backoff(10, nil))
+
+	resp := &http.Response{Header: http.Header{"Retry-After": {"3"}}}
+	require.Equal(t, 3*time.Second, p.backoff(0, resp))
+}
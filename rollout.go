@@ -0,0 +1,154 @@
+package winvps
+
+import (
+	"fmt"
+	"time"
+)
+
+// Represents rolling restart or updates installation options
+type RolloutOptions struct {
+	// Number of canary machines processed one by one before batches, 1 by default
+	Canaries int
+	// Number of machines processed at once after canaries, 1 by default
+	BatchSize int
+	// Pause between batches
+	Pause time.Duration
+	// Maximum number of failed machines, rollout halts when it's exceeded.
+	// Failed canary always halts the rollout
+	MaxFailures int
+	// Jobs and machine status poll interval, 5s by default
+	Interval time.Duration
+	// Maximum wait for jobs and machine status of a single machine, 0 means wait forever
+	Timeout time.Duration
+	// Called after each processed batch with results of the batch
+	Progress func(batch BulkResults)
+}
+
+// Rolling "restart" or "run_updates_install" over machines: canary machines first, then
+// batches with a pause between them. Each machine is verified to return to Running, and for
+// updates a pending reboot is done and verified to clear. Protected machines, see Guard(), are
+// skipped and don't halt the rollout. Results of processed machines are returned, error is
+// returned if rollout halted
+func (c *Client) Rollout(names []string, command string, opt *RolloutOptions) (BulkResults, error) {
+	if command != "restart" && command != "run_updates_install" {
+		return nil, fmt.Errorf("allowed rollout command 'restart' or 'run_updates_install' but '%s' passed", command)
+	}
+	if opt == nil {
+		opt = &RolloutOptions{}
+	}
+	canaries, batchSize := opt.Canaries, opt.BatchSize
+	if canaries <= 0 {
+		canaries = 1
+	}
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	interval := opt.Interval
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+
+	// protected set is fetched once, not per batch
+	protected, err := c.ProtectedMachines()
+	if err != nil {
+		return nil, fmt.Errorf("unable to check protection: %v", err)
+	}
+	process := func(name string) ([]*Job, error) {
+		return c.rolloutMachine(name, command, interval, opt.Timeout)
+	}
+
+	var results BulkResults
+	failures := 0
+	for start := 0; start < len(names); {
+		size, canary := batchSize, start < canaries
+		if canary {
+			size = 1
+		}
+		end := start + size
+		if end > len(names) {
+			end = len(names)
+		}
+		if start > 0 && opt.Pause > 0 {
+			time.Sleep(opt.Pause)
+		}
+
+		batch := c.bulk(names[start:end], &BulkOptions{Concurrency: size}, protected, nil, process)
+		results = append(results, batch...)
+		if opt.Progress != nil {
+			opt.Progress(batch)
+		}
+		failed := batch.Failed()
+		failures += len(failed)
+		if canary && len(failed) > 0 {
+			return results, fmt.Errorf("rollout halted, canary %s failed: %s", failed[0].Machine, failed[0].Error)
+		}
+		if failures > opt.MaxFailures {
+			return results, fmt.Errorf("rollout halted, %d machines failed, allowed %d", failures, opt.MaxFailures)
+		}
+		start = end
+	}
+	return results, nil
+}
+
+// Send command to a single machine and verify it's back to running state
+func (c *Client) rolloutMachine(name, command string, interval, timeout time.Duration) ([]*Job, error) {
+	jobs, err := c.SendMachineCommand(name, command)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.WaitJobs(jobs, interval, timeout); err != nil {
+		return jobs, err
+	}
+	m, err := c.waitRunning(name, interval, timeout)
+	if err != nil {
+		return jobs, err
+	}
+	if command != "run_updates_install" || !rebootRequired(m) {
+		return jobs, nil
+	}
+
+	restart, err := c.SendMachineCommand(name, "restart")
+	jobs = append(jobs, restart...)
+	if err != nil {
+		return jobs, err
+	}
+	if err := c.WaitJobs(restart, interval, timeout); err != nil {
+		return jobs, err
+	}
+	if m, err = c.waitRunning(name, interval, timeout); err != nil {
+		return jobs, err
+	}
+	if rebootRequired(m) {
+		return jobs, fmt.Errorf("machine %s still requires reboot after restart", name)
+	}
+	return jobs, nil
+}
+
+// Polls machine until it's running
+func (c *Client) waitRunning(name string, interval, timeout time.Duration) (*MachineFull, error) {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	for {
+		m, err := c.GetMachine(name)
+		if err != nil {
+			return nil, err
+		}
+		if m.Machine != nil && m.Status == MachineStatusRunning {
+			return m, nil
+		}
+		if !deadline.IsZero() && time.Now().Add(interval).After(deadline) {
+			status := ""
+			if m.Machine != nil {
+				status = m.Status
+			}
+			return m, fmt.Errorf("timeout waiting for machine %s to run, status: %s", name, status)
+		}
+		time.Sleep(interval)
+	}
+}
+
+func rebootRequired(m *MachineFull) bool {
+	return m.OS != nil && m.OS.UpdateStatus != nil && m.OS.UpdateStatus.RebootRequired
+}
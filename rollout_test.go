@@ -0,0 +1,95 @@
+package winvps
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fake machines api with reboot tracking
+type fakeFleet struct {
+	mu       sync.Mutex
+	reboot   map[string]bool
+	failing  map[string]bool
+	commands []string
+}
+
+func (f *fakeFleet) register(mux *http.ServeMux) {
+	mux.HandleFunc(apiVerPath+"jobs/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"id":1,"status":"Complete"}}`)
+	})
+	mux.HandleFunc(apiVerPath+"machines/", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, apiVerPath+"machines/"), "/")
+		name := parts[0]
+		if len(parts) == 1 {
+			fmt.Fprintf(w, `{"data":{"name":"%s","status":"Running","os":{"update_status":{"reboot_required":%t}}}}`, name, f.reboot[name])
+			return
+		}
+		f.commands = append(f.commands, name+" "+parts[1])
+		if f.failing[name] {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"error":"internal"}`)
+			return
+		}
+		switch parts[1] {
+		case "run_updates_install":
+			f.reboot[name] = true
+		case "restart":
+			f.reboot[name] = false
+		}
+		fmt.Fprint(w, `{"data":{"jobs":[{"id":1,"status":"Pending"}]}}`)
+	})
+}
+
+func TestRolloutUpdates(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	f := &fakeFleet{reboot: map[string]bool{}, failing: map[string]bool{}}
+	f.register(mux)
+
+	var batches []int
+	names := []string{"VPS01", "VPS02", "VPS03", "VPS04", "VPS05"}
+	results, err := client.Rollout(names, "run_updates_install", &RolloutOptions{
+		BatchSize: 2,
+		Interval:  time.Millisecond,
+		Progress:  func(batch BulkResults) { batches = append(batches, len(batch)) },
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+	require.NoError(t, results.Err())
+	require.Equal(t, []int{1, 2, 2}, batches)
+	require.Len(t, results[0].Jobs, 2)
+	require.Equal(t, []string{"VPS01 run_updates_install", "VPS01 restart"}, f.commands[:2])
+	require.Len(t, f.commands, 10)
+}
+
+func TestRolloutHalts(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	f := &fakeFleet{reboot: map[string]bool{}, failing: map[string]bool{"VPS01": true}}
+	f.register(mux)
+
+	names := []string{"VPS01", "VPS02", "VPS03", "VPS04", "VPS05"}
+	results, err := client.Rollout(names, "restart", &RolloutOptions{Interval: time.Millisecond, MaxFailures: 5})
+	require.EqualError(t, err, "rollout halted, canary VPS01 failed: status: 500, error: internal")
+	require.Len(t, results, 1)
+
+	f.failing = map[string]bool{"VPS02": true, "VPS03": true}
+	f.commands = nil
+	results, err = client.Rollout(names, "restart", &RolloutOptions{Interval: time.Millisecond, MaxFailures: 1})
+	require.EqualError(t, err, "rollout halted, 2 machines failed, allowed 1")
+	require.Len(t, results, 3)
+	require.Len(t, f.commands, 3)
+
+	_, err = client.Rollout(names, "stop", nil)
+	require.EqualError(t, err, "allowed rollout command 'restart' or 'run_updates_install' but 'stop' passed")
+}
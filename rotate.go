@@ -0,0 +1,222 @@
+package winvps
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Represents a destination of rotated machine passwords, safe for concurrent use
+type SecretSink interface {
+	Store(machine, password string) error
+}
+
+// Adapts a function to SecretSink
+type SecretSinkFunc func(machine, password string) error
+
+// Store calls f(machine, password)
+func (f SecretSinkFunc) Store(machine, password string) error {
+	return f(machine, password)
+}
+
+// Represents a sink keeping a stored password pending until its change is confirmed.
+// RotatePasswords commits the password once the change is confirmed and rolls it back if the
+// change is rejected. If the outcome is unknown the password stays pending for recovery
+type PendingSecretSink interface {
+	SecretSink
+	// Make the pending password of machine its current password
+	Commit(machine string) error
+	// Discard the pending password of machine, keeping the previous one current
+	Rollback(machine string) error
+}
+
+// Represents a sink writing tab separated machine and password lines to a writer
+type writerSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// Returns sink writing "machine<TAB>password" lines to w, e.g. os.Stdout
+func WriterSink(w io.Writer) SecretSink {
+	return &writerSink{w: w}
+}
+
+func (s *writerSink) Store(machine, password string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := fmt.Fprintf(s.w, "%s\t%s\n", machine, password)
+	return err
+}
+
+// Represents a sink appending password lines to a file
+type fileSink struct {
+	mu      sync.Mutex
+	path    string
+	pending map[string]string
+}
+
+// Returns sink appending lines to the file, which is created readable by the owner only.
+// A stored password is appended as "machine<TAB>password<TAB>pending" line, once its change is
+// confirmed it's appended again as "machine<TAB>password". The last line of a machine without
+// pending mark holds its current password, pending lines of failed changes are left for recovery
+func FileSink(path string) PendingSecretSink {
+	return &fileSink{path: path, pending: map[string]string{}}
+}
+
+func (s *fileSink) Store(machine, password string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := appendLine(s.path, machine+"\t"+password+"\tpending"); err != nil {
+		return err
+	}
+	s.pending[machine] = password
+	return nil
+}
+
+func (s *fileSink) Commit(machine string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	password, ok := s.pending[machine]
+	if !ok {
+		return fmt.Errorf("no pending password of %s", machine)
+	}
+	if err := appendLine(s.path, machine+"\t"+password); err != nil {
+		return err
+	}
+	delete(s.pending, machine)
+	return nil
+}
+
+func (s *fileSink) Rollback(machine string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, machine)
+	return nil
+}
+
+// Append the line to the file and flush it to disk
+func appendLine(path, line string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(f, line); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Represents password rotation options
+type RotateOptions struct {
+	// Sink receiving new passwords, required
+	Sink SecretSink
+	// Generator options of new passwords
+	Password *PasswordOptions
+	// File listing machines already rotated, they are skipped and each rotated machine is
+	// appended, so an interrupted rotation can be resumed. Remove it to start a new rotation
+	StateFile string
+	// Maximum number of machines processed at once, 10 by default
+	Concurrency int
+}
+
+// Rotate administrator password of each machine: generate a new password, store it in the sink
+// and change it. The password is stored before the change, so a changed password is never lost.
+// If the sink is a PendingSecretSink the password is committed once the change is confirmed and
+// rolled back if the api rejected it, keeping the previous password current. If the outcome is
+// unknown, e.g. on a transport error, timeout or server error, the password is left pending in
+// the sink for recovery as it may have been applied. Results of processed
+// machines are returned, machines listed in the state file are skipped. In dry-run mode neither
+// the sink nor the state file are written
+func (c *Client) RotatePasswords(names []string, opt *RotateOptions) (BulkResults, error) {
+	if opt == nil || opt.Sink == nil {
+		return nil, fmt.Errorf("missing required option Sink")
+	}
+	done, err := readRotateState(opt.StateFile)
+	if err != nil {
+		return nil, err
+	}
+	var pending []string
+	for _, name := range names {
+		if !done[name] {
+			pending = append(pending, name)
+		}
+	}
+
+	mu := sync.Mutex{}
+	dryRun := c.DryRunEnabled()
+	return c.Bulk(pending, &BulkOptions{Concurrency: opt.Concurrency}, func(name string) ([]*Job, error) {
+		password, err := GeneratePassword(opt.Password)
+		if err != nil {
+			return nil, err
+		}
+		if dryRun {
+			_, err := c.ChangeMachinePassword(name, password)
+			return nil, err
+		}
+		if err := opt.Sink.Store(name, password); err != nil {
+			return nil, fmt.Errorf("unable to store password: %v", err)
+		}
+		pending, _ := opt.Sink.(PendingSecretSink)
+		ok, status, err := c.changeMachinePassword(name, password)
+		// the change is known not to be applied only if the api rejected it
+		rejected := (err == nil && !ok) || (status >= 400 && status < 500)
+		if err == nil && !ok {
+			err = fmt.Errorf("password change of %s not confirmed", name)
+		}
+		if err != nil {
+			switch {
+			case pending == nil:
+			case rejected:
+				if rerr := pending.Rollback(name); rerr != nil {
+					return nil, fmt.Errorf("%v, unable to roll back stored password: %v", err, rerr)
+				}
+			default:
+				return nil, fmt.Errorf("%v, change may have been applied, new password is left pending in the sink", err)
+			}
+			return nil, err
+		}
+		if pending != nil {
+			if err := pending.Commit(name); err != nil {
+				return nil, fmt.Errorf("password changed, unable to commit stored password: %v", err)
+			}
+		}
+		if opt.StateFile == "" {
+			return nil, nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return nil, appendLine(opt.StateFile, name)
+	}), nil
+}
+
+// Returns machines listed in the state file, empty if it doesn't exist
+func readRotateState(path string) (map[string]bool, error) {
+	done := map[string]bool{}
+	if path == "" {
+		return done, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if name := strings.TrimSpace(scanner.Text()); name != "" {
+			done[name] = true
+		}
+	}
+	return done, scanner.Err()
+}
@@ -0,0 +1,162 @@
+package winvps
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatePasswords(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	mu := sync.Mutex{}
+	changed := map[string]string{}
+	for _, name := range []string{"VPS01", "VPS02", "VPS03"} {
+		name := name
+		mux.HandleFunc(apiVerPath+"machines/"+name+"/change_password", func(w http.ResponseWriter, r *http.Request) {
+			var body password
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			mu.Lock()
+			changed[name] = body.Password.Reveal()
+			mu.Unlock()
+			fmt.Fprintf(w, `{"data":{"result":%t}}`, name != "VPS02")
+		})
+	}
+
+	dir := t.TempDir()
+	secrets, state := filepath.Join(dir, "secrets"), filepath.Join(dir, "state")
+	require.NoError(t, os.WriteFile(state, []byte("VPS03\n"), 0600))
+
+	results, err := client.RotatePasswords([]string{"VPS01", "VPS02", "VPS03"}, &RotateOptions{Sink: FileSink(secrets), StateFile: state})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.True(t, results[0].Success)
+	require.Equal(t, "password change of VPS02 not confirmed", results[1].Error)
+	require.NotContains(t, changed, "VPS03")
+	require.NoError(t, ValidatePassword(changed["VPS01"]))
+
+	data, err := os.ReadFile(secrets)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.ElementsMatch(t, []string{
+		"VPS01\t" + changed["VPS01"] + "\tpending",
+		"VPS01\t" + changed["VPS01"],
+		"VPS02\t" + changed["VPS02"] + "\tpending",
+	}, lines)
+	info, err := os.Stat(secrets)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	data, err = os.ReadFile(state)
+	require.NoError(t, err)
+	require.Equal(t, "VPS03\nVPS01\n", string(data))
+
+	// resume rotates failed machine only
+	delete(changed, "VPS01")
+	results, err = client.RotatePasswords([]string{"VPS01", "VPS02", "VPS03"}, &RotateOptions{Sink: WriterSink(new(strings.Builder)), StateFile: state})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "VPS02", results[0].Machine)
+	require.NotContains(t, changed, "VPS01")
+}
+
+func TestRotatePasswordsSinkFailure(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	mux.HandleFunc(apiVerPath+"machines/VPS01/change_password", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("password must not be changed when it can't be stored")
+	})
+
+	sink := SecretSinkFunc(func(machine, password string) error {
+		return fmt.Errorf("vault unavailable")
+	})
+	results, err := client.RotatePasswords([]string{"VPS01"}, &RotateOptions{Sink: sink})
+	require.NoError(t, err)
+	require.EqualError(t, results.Err(), "1 of 1 machines failed, first error: VPS01: unable to store password: vault unavailable")
+
+	_, err = client.RotatePasswords([]string{"VPS01"}, nil)
+	require.EqualError(t, err, "missing required option Sink")
+}
+
+// Records calls of PendingSecretSink
+type pendingSink struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (s *pendingSink) record(call string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, call)
+	return nil
+}
+
+func (s *pendingSink) Store(machine, password string) error { return s.record("store " + machine) }
+func (s *pendingSink) Commit(machine string) error          { return s.record("commit " + machine) }
+func (s *pendingSink) Rollback(machine string) error        { return s.record("rollback " + machine) }
+
+func TestRotatePasswordsRollback(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	mux.HandleFunc(apiVerPath+"machines/VPS01/change_password", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"result":true}}`)
+	})
+	mux.HandleFunc(apiVerPath+"machines/VPS02/change_password", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"result":false}}`)
+	})
+	mux.HandleFunc(apiVerPath+"machines/VPS03/change_password", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":"password is too weak"}`)
+	})
+	mux.HandleFunc(apiVerPath+"machines/VPS04/change_password", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"error":"internal error"}`)
+	})
+
+	sink := &pendingSink{}
+	results, err := client.RotatePasswords([]string{"VPS01", "VPS02", "VPS03", "VPS04"}, &RotateOptions{Sink: sink, Concurrency: 1})
+	require.NoError(t, err)
+	require.Len(t, results.Failed(), 3)
+	// server error doesn't tell whether the change was applied, so the password stays pending
+	require.Equal(t, "status: 500, error: internal error, change may have been applied, new password is left pending in the sink", results[3].Error)
+	require.Equal(t, []string{
+		"store VPS01", "commit VPS01",
+		"store VPS02", "rollback VPS02",
+		"store VPS03", "rollback VPS03",
+		"store VPS04",
+	}, sink.calls)
+}
+
+func TestRotatePasswordsDryRun(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+	require.NoError(t, DryRun(true)(client))
+	require.True(t, client.DryRunEnabled())
+
+	mux.HandleFunc(apiVerPath+"machines/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("mutating request must not be sent: %s %s", r.Method, r.URL)
+	})
+
+	dir := t.TempDir()
+	state := filepath.Join(dir, "state")
+	sink := &pendingSink{}
+	results, err := client.RotatePasswords([]string{"VPS01", "VPS02"}, &RotateOptions{Sink: sink, StateFile: state})
+	require.NoError(t, err)
+	require.NoError(t, results.Err())
+	require.Empty(t, sink.calls)
+	require.NoFileExists(t, state)
+	require.Len(t, client.DryRunRequests(), 2)
+
+	require.NoError(t, DryRun(false)(client))
+	require.False(t, client.DryRunEnabled())
+}
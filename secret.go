@@ -0,0 +1,81 @@
+package winvps
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Represents a sensitive string, e.g. a password. It's marshalled to json as is, but is
+// redacted when printed with fmt, including %+v and %#v of structs holding it, and slog.
+// slog.JSONHandler marshals structs to json, so types of this package holding a secret
+// implement slog.LogValuer to keep it redacted. Slices of them are marshalled as is by
+// slog.JSONHandler, log their elements instead.
+// An empty secret is printed as empty string. Go strings are immutable, so the value can't
+// be zeroed in memory, keep secrets short-lived instead
+type Secret string
+
+// Returns the secret value
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// Returns redacted placeholder
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// Returns redacted placeholder for %#v
+func (s Secret) GoString() string {
+	return strconv.Quote(s.String())
+}
+
+// Format implements fmt.Formatter, so no verb prints the secret value
+func (s Secret) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'q':
+		io.WriteString(f, strconv.Quote(s.String()))
+	case 'v':
+		if f.Flag('#') {
+			io.WriteString(f, s.GoString())
+			return
+		}
+		io.WriteString(f, s.String())
+	default:
+		io.WriteString(f, s.String())
+	}
+}
+
+// LogValue implements slog.LogValuer
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// Returns group of exported struct fields keyed by their json names. Secret fields are
+// redacted by their LogValue, so the group is safe to log with any slog handler
+func structLogValue(v interface{}) slog.Value {
+	rv := reflect.ValueOf(v)
+	rt := rv.Type()
+	attrs := make([]slog.Attr, 0, rt.NumField())
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		attrs = append(attrs, slog.Any(name, rv.Field(i).Interface()))
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,65 @@
+package winvps
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecret(t *testing.T) {
+	s := Secret("Secret-123")
+	for _, format := range []string{"%s", "%v", "%+v", "%#v", "%q", "%x", "%d", "%10s"} {
+		require.NotContains(t, fmt.Sprintf(format, s), "Secret-123", format)
+	}
+	require.Equal(t, "[REDACTED]", fmt.Sprint(s))
+	require.Equal(t, `"[REDACTED]"`, fmt.Sprintf("%#v", s))
+	require.Equal(t, "", Secret("").String())
+	require.Equal(t, "Secret-123", s.Reveal())
+
+	opt := &CreateMachineOptions{ProductID: 1, Password: s}
+	user := User{Username: "admin", Password: s}
+	for _, v := range []string{fmt.Sprintf("%+v", opt), fmt.Sprintf("%#v", opt), fmt.Sprintf("%v", user), fmt.Sprintf("%+v", []User{user})} {
+		require.NotContains(t, v, "Secret-123")
+		require.Contains(t, v, "REDACTED")
+	}
+
+	data, err := json.Marshal(user)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"username":"admin","role":"","password":"Secret-123"}`, string(data))
+	require.NoError(t, json.Unmarshal([]byte(`{"password":"Other-123"}`), &user))
+	require.Equal(t, Secret("Other-123"), user.Password)
+
+	b := new(bytes.Buffer)
+	slog.New(slog.NewTextHandler(b, nil)).Info("user", "password", s, "user", user)
+	require.NotContains(t, b.String(), "Secret-123")
+	require.NotContains(t, b.String(), "Other-123")
+
+	// json handler marshals structs, so they must redact passwords themselves
+	b.Reset()
+	logger := slog.New(slog.NewJSONHandler(b, nil))
+	logger.Info("create", "password", s, "opt", opt, "user", user,
+		"update", &UpdateMachineOptions{Password: s}, "reinstall", ReinstallMachineOptions{Password: s, TemplateID: 2},
+		"additional", AdditionalUser{Username: "admin", Password: s})
+	require.NotContains(t, b.String(), "Secret-123")
+	require.NotContains(t, b.String(), "Other-123")
+	entry := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(b.Bytes(), &entry))
+	require.Equal(t, "[REDACTED]", entry["password"])
+	require.Equal(t, map[string]interface{}{"username": "admin", "role": "", "password": "[REDACTED]"}, entry["user"])
+	require.Equal(t, "[REDACTED]", entry["opt"].(map[string]interface{})["password"])
+	require.Equal(t, float64(1), entry["opt"].(map[string]interface{})["product_id"])
+	require.Equal(t, float64(2), entry["reinstall"].(map[string]interface{})["template_id"])
+}
+
+func TestCheckRequiredOptsDoesNotLeak(t *testing.T) {
+	opt := &CreateMachineOptions{Password: "Secret-123"}
+	err := checkRequiredOpts(opt, []string{"Missing"})
+	require.EqualError(t, err, "struct *winvps.CreateMachineOptions not contain required field Missing")
+
+	require.EqualError(t, checkRequiredOpts(&password{}, []string{"Password"}), "missing required option Password")
+	require.NoError(t, checkRequiredOpts(&password{Password: "x"}, []string{"Password"}))
+}
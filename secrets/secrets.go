@@ -0,0 +1,306 @@
+// Package secrets keeps machine credentials in an encrypted local file.
+//
+// Credentials are keyed by machine name and username. The file is sealed with NaCl secretbox
+// using a key derived from a passphrase with scrypt, so credentials never land on disk in
+// plaintext. The store can be used as a winvps.PendingSecretSink of password rotation.
+package secrets
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/fozzyhosting/winvps-go-client"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Username of the machine administrator used by Sink() for rotated passwords
+const Administrator = "Administrator"
+
+// Represents a credentials store
+type Store interface {
+	// Returns password of the user on machine
+	Get(machine, username string) (string, error)
+	// Set password of the user on machine
+	Set(machine, username, password string) error
+	// Delete password of the user on machine
+	Delete(machine, username string) error
+	// Returns keys of all stored passwords sorted by machine and username
+	List() ([]Key, error)
+}
+
+// Represents a key of a stored password
+type Key struct {
+	Machine  string `json:"machine"`
+	Username string `json:"username"`
+}
+
+// Returns key as username@machine
+func (k Key) String() string {
+	return k.Username + "@" + k.Machine
+}
+
+// File format: magic, scrypt salt, secretbox nonce and sealed json
+var magic = []byte("WVS1")
+
+const (
+	saltSize  = 16
+	nonceSize = 24
+)
+
+// Represents a store kept in an encrypted file, safe for concurrent use
+type FileStore struct {
+	path string
+	salt []byte
+	key  [32]byte
+
+	mu sync.Mutex
+}
+
+// Open encrypted store file, it's created on first write if it doesn't exist.
+// Error is returned if the passphrase can't decrypt existing file
+func OpenFile(path string, passphrase []byte) (*FileStore, error) {
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("empty passphrase")
+	}
+	s := &FileStore{path: path}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		s.salt = make([]byte, saltSize)
+		if _, err := io.ReadFull(rand.Reader, s.salt); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	default:
+		if len(data) < len(magic)+saltSize+nonceSize || !bytes.Equal(data[:len(magic)], magic) {
+			return nil, fmt.Errorf("%s is not a secrets file", path)
+		}
+		s.salt = data[len(magic) : len(magic)+saltSize]
+	}
+	if err := s.deriveKey(passphrase); err != nil {
+		return nil, err
+	}
+	if _, err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileStore) deriveKey(passphrase []byte) error {
+	key, err := scrypt.Key(passphrase, s.salt, 1<<15, 8, 1, len(s.key))
+	if err != nil {
+		return err
+	}
+	copy(s.key[:], key)
+	return nil
+}
+
+// Returns decrypted passwords keyed by machine and username
+func (s *FileStore) load() (map[string]map[string]string, error) {
+	entries := map[string]map[string]string{}
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var nonce [nonceSize]byte
+	copy(nonce[:], data[len(magic)+saltSize:])
+	plain, ok := secretbox.Open(nil, data[len(magic)+saltSize+nonceSize:], &nonce, &s.key)
+	if !ok {
+		return nil, fmt.Errorf("unable to decrypt %s, wrong passphrase or corrupted file", s.path)
+	}
+	if err := json.Unmarshal(plain, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Encrypt and atomically replace the file
+func (s *FileStore) save(entries map[string]map[string]string) error {
+	plain, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	var nonce [nonceSize]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return err
+	}
+	data := append(append(append([]byte{}, magic...), s.salt...), nonce[:]...)
+	data = secretbox.Seal(data, plain, &nonce, &s.key)
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path)
+}
+
+// Returns password of the user on machine
+func (s *FileStore) Get(machine, username string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	password, ok := entries[machine][username]
+	if !ok {
+		return "", fmt.Errorf("no password of %s", Key{machine, username})
+	}
+	return password, nil
+}
+
+// Set password of the user on machine
+func (s *FileStore) Set(machine, username, password string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+	if entries[machine] == nil {
+		entries[machine] = map[string]string{}
+	}
+	entries[machine][username] = password
+	return s.save(entries)
+}
+
+// Delete password of the user on machine, deleting missing password isn't an error
+func (s *FileStore) Delete(machine, username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := entries[machine][username]; !ok {
+		return nil
+	}
+	delete(entries[machine], username)
+	if len(entries[machine]) == 0 {
+		delete(entries, machine)
+	}
+	return s.save(entries)
+}
+
+// Returns keys of all stored passwords sorted by machine and username
+func (s *FileStore) List() ([]Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	var keys []Key
+	for machine, users := range entries {
+		for username := range users {
+			keys = append(keys, Key{Machine: machine, Username: username})
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Machine != keys[j].Machine {
+			return keys[i].Machine < keys[j].Machine
+		}
+		return keys[i].Username < keys[j].Username
+	})
+	return keys, nil
+}
+
+// Suffix of the username a rotated password is kept under until its change is confirmed
+const PendingSuffix = ".pending"
+
+// Represents a rotation sink storing passwords of the user
+type sink struct {
+	s        Store
+	username string
+}
+
+// Returns sink storing rotated passwords as passwords of the user, Administrator if empty.
+// A new password is stored as password of the user with PendingSuffix and becomes the user
+// password once the change is confirmed, it's deleted if the change is rejected. If the outcome
+// of the change is unknown, the pending password is left for recovery
+func Sink(s Store, username string) winvps.PendingSecretSink {
+	if username == "" {
+		username = Administrator
+	}
+	return &sink{s: s, username: username}
+}
+
+func (k *sink) Store(machine, password string) error {
+	return k.s.Set(machine, k.username+PendingSuffix, password)
+}
+
+func (k *sink) Commit(machine string) error {
+	password, err := k.s.Get(machine, k.username+PendingSuffix)
+	if err != nil {
+		return err
+	}
+	if err := k.s.Set(machine, k.username, password); err != nil {
+		return err
+	}
+	return k.s.Delete(machine, k.username+PendingSuffix)
+}
+
+func (k *sink) Rollback(machine string) error {
+	return k.s.Delete(machine, k.username+PendingSuffix)
+}
+
+// Generate a password and store it as password of the user on machine
+func Generate(s Store, machine, username string, opt *winvps.PasswordOptions) (string, error) {
+	password, err := winvps.GeneratePassword(opt)
+	if err != nil {
+		return "", err
+	}
+	if err := s.Set(machine, username, password); err != nil {
+		return "", err
+	}
+	return password, nil
+}
+
+// Store passwords of machine additional users returned by the api, returns number of stored users
+func ImportUsers(s Store, c *winvps.Client, machine string) (int, error) {
+	users, err := winvps.ListAll(func(opts ...*winvps.RequestOptions) ([]*winvps.User, *winvps.Pagination, error) {
+		return c.GetMachineUsers(machine, opts...)
+	})
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, u := range users {
+		if u.Password == "" {
+			continue
+		}
+		if err := s.Set(machine, u.Username, u.Password.Reveal()); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,148 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fozzyhosting/winvps-go-client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	s, err := OpenFile(path, []byte("passphrase"))
+	require.NoError(t, err)
+
+	keys, err := s.List()
+	require.NoError(t, err)
+	require.Empty(t, keys)
+	_, err = s.Get("VPS01", "admin")
+	require.EqualError(t, err, "no password of admin@VPS01")
+
+	require.NoError(t, s.Set("VPS02", "admin", "Secret-2"))
+	require.NoError(t, s.Set("VPS01", "user", "Secret-1u"))
+	require.NoError(t, s.Set("VPS01", "admin", "Secret-1"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "Secret")
+	require.NotContains(t, string(data), "VPS01")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	s, err = OpenFile(path, []byte("passphrase"))
+	require.NoError(t, err)
+	password, err := s.Get("VPS01", "admin")
+	require.NoError(t, err)
+	require.Equal(t, "Secret-1", password)
+	keys, err = s.List()
+	require.NoError(t, err)
+	require.Equal(t, []Key{{"VPS01", "admin"}, {"VPS01", "user"}, {"VPS02", "admin"}}, keys)
+
+	require.NoError(t, s.Delete("VPS02", "admin"))
+	require.NoError(t, s.Delete("VPS02", "admin"))
+	keys, err = s.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+
+	_, err = OpenFile(path, []byte("wrong"))
+	require.EqualError(t, err, "unable to decrypt "+path+", wrong passphrase or corrupted file")
+	_, err = OpenFile(path, nil)
+	require.EqualError(t, err, "empty passphrase")
+
+	require.NoError(t, os.WriteFile(path, []byte("plain text file with no magic"), 0600))
+	_, err = OpenFile(path, []byte("passphrase"))
+	require.EqualError(t, err, path+" is not a secrets file")
+}
+
+func TestSinkAndImport(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/api/v2/machines/VPS01/users", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":[{"username":"admin","role":"admin","password":"Secret-1"},{"username":"nopass","role":"user"}],"pagination":{"total":2,"limit":50,"page":1,"pages":1}}`)
+	})
+	mux.HandleFunc("/api/v2/machines/VPS01/change_password", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"result":true}}`)
+	})
+	mux.HandleFunc("/api/v2/machines/VPS03/change_password", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data":{"result":false}}`)
+	})
+	mux.HandleFunc("/api/v2/machines/VPS04/change_password", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":"password is too weak"}`)
+	})
+	// the server applies the change, but the connection breaks before the response
+	applied := ""
+	mux.HandleFunc("/api/v2/machines/VPS05/change_password", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Password string `json:"password"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		applied = body.Password
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		conn.Close()
+	})
+
+	client, err := winvps.NewClient("secret", winvps.BaseURL(server.URL))
+	require.NoError(t, err)
+	s, err := OpenFile(filepath.Join(t.TempDir(), "secrets.enc"), []byte("passphrase"))
+	require.NoError(t, err)
+
+	n, err := ImportUsers(s, client, "VPS01")
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	password, err := s.Get("VPS01", "admin")
+	require.NoError(t, err)
+	require.Equal(t, "Secret-1", password)
+
+	for _, machine := range []string{"VPS01", "VPS03", "VPS04", "VPS05"} {
+		require.NoError(t, s.Set(machine, Administrator, "Old-"+machine))
+	}
+	results, err := client.RotatePasswords([]string{"VPS01", "VPS03", "VPS04", "VPS05"}, &winvps.RotateOptions{Sink: Sink(s, "")})
+	require.NoError(t, err)
+	require.Len(t, results.Failed(), 3)
+	password, err = s.Get("VPS01", Administrator)
+	require.NoError(t, err)
+	require.NotEqual(t, "Old-VPS01", password)
+	require.NoError(t, winvps.ValidatePassword(password))
+
+	// rejected changes keep the previous password
+	for _, machine := range []string{"VPS03", "VPS04"} {
+		password, err = s.Get(machine, Administrator)
+		require.NoError(t, err)
+		require.Equal(t, "Old-"+machine, password)
+	}
+
+	// change with unknown outcome keeps the new password pending for recovery
+	require.Contains(t, results[3].Error, "change may have been applied, new password is left pending in the sink")
+	password, err = s.Get("VPS05", Administrator)
+	require.NoError(t, err)
+	require.Equal(t, "Old-VPS05", password)
+	pending, err := s.Get("VPS05", Administrator+PendingSuffix)
+	require.NoError(t, err)
+	require.NotEmpty(t, applied)
+	require.Equal(t, applied, pending)
+
+	keys, err := s.List()
+	require.NoError(t, err)
+	require.Equal(t, []Key{
+		{"VPS01", Administrator}, {"VPS01", "admin"},
+		{"VPS03", Administrator}, {"VPS04", Administrator},
+		{"VPS05", Administrator}, {"VPS05", Administrator + PendingSuffix},
+	}, keys)
+
+	password, err = Generate(s, "VPS02", "admin", nil)
+	require.NoError(t, err)
+	stored, err := s.Get("VPS02", "admin")
+	require.NoError(t, err)
+	require.Equal(t, password, stored)
+}
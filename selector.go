@@ -0,0 +1,253 @@
+package winvps
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Represents an inclusive integer range, zero Max means no upper bound
+type Range struct {
+	Min int
+	Max int
+}
+
+// Reports whether v is within the range
+func (r Range) Contains(v int) bool {
+	return v >= r.Min && (r.Max == 0 || v <= r.Max)
+}
+
+// Represents client-side machine selection criteria, zero fields match any machine.
+// Machine location isn't reported by the api, so machines can't be selected by location
+type Selector struct {
+	// Machine status, compared case-insensitively
+	Status string
+	// Machine name glob pattern, e.g. VPS01*
+	Name string
+	// Machine name regular expression
+	NameRegexp string
+	// Substring of machine notes
+	Notes      string
+	TemplateID string
+	BrandID    int
+	// Product matched by machine config, see MatchProduct()
+	ProductID int
+	// IP address or CIDR containing any of machine IPs
+	IP string
+	// Maximal RAM range
+	Ram Range
+	// CPU cores range
+	Cpu Range
+}
+
+// Parse selector from comma separated key=value pairs, e.g.
+//
+//	status=running,name=VPS0*,ip=10.0.0.0/8,ram=2048-,cpu=2-4
+//
+// Available keys are status, name, regexp, notes, template, brand, product, ip, ram and cpu.
+// Ranges are MIN-MAX, MIN- or a single value
+func ParseSelector(s string) (*Selector, error) {
+	sel := &Selector{}
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid selector '%s', expected key=value", pair)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		var err error
+		switch key {
+		case "status":
+			sel.Status = value
+		case "name":
+			sel.Name = value
+		case "regexp":
+			sel.NameRegexp = value
+		case "notes":
+			sel.Notes = value
+		case "template":
+			sel.TemplateID = value
+		case "brand":
+			sel.BrandID, err = strconv.Atoi(value)
+		case "product":
+			sel.ProductID, err = strconv.Atoi(value)
+		case "ip":
+			sel.IP = value
+		case "ram":
+			sel.Ram, err = parseRange(value)
+		case "cpu":
+			sel.Cpu, err = parseRange(value)
+		case "location":
+			return nil, fmt.Errorf("selecting by location isn't supported, the api doesn't report machine location")
+		default:
+			return nil, fmt.Errorf("unknown selector key '%s'", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %s value '%s'", key, value)
+		}
+	}
+	return sel, nil
+}
+
+func parseRange(s string) (Range, error) {
+	min, max, ok := strings.Cut(s, "-")
+	r := Range{}
+	var err error
+	if r.Min, err = strconv.Atoi(min); err != nil {
+		return r, err
+	}
+	if !ok {
+		r.Max = r.Min
+		return r, nil
+	}
+	if max != "" {
+		if r.Max, err = strconv.Atoi(max); err != nil {
+			return r, err
+		}
+	}
+	return r, nil
+}
+
+// Reports whether the selector needs products to match machines
+func (s *Selector) NeedsProducts() bool {
+	return s.ProductID != 0
+}
+
+// Returns filter matching machines selected by s, products are required to select by product
+func (s *Selector) Filter(products []*Product) (MachineFilter, error) {
+	var filters []MachineFilter
+	add := func(f MachineFilter) {
+		filters = append(filters, f)
+	}
+
+	if s.Status != "" {
+		add(func(m *MachineFull) bool { return strings.EqualFold(m.Status, s.Status) })
+	}
+	if s.Name != "" {
+		if _, err := path.Match(s.Name, ""); err != nil {
+			return nil, fmt.Errorf("invalid name pattern '%s': %v", s.Name, err)
+		}
+		add(func(m *MachineFull) bool {
+			ok, _ := path.Match(s.Name, m.Name)
+			return ok
+		})
+	}
+	if s.NameRegexp != "" {
+		re, err := regexp.Compile(s.NameRegexp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid name regexp '%s': %v", s.NameRegexp, err)
+		}
+		add(func(m *MachineFull) bool { return re.MatchString(m.Name) })
+	}
+	if s.Notes != "" {
+		add(func(m *MachineFull) bool { return strings.Contains(m.Notes, s.Notes) })
+	}
+	if s.TemplateID != "" {
+		add(func(m *MachineFull) bool { return m.OS != nil && m.OS.TemplateID == s.TemplateID })
+	}
+	if s.BrandID != 0 {
+		add(func(m *MachineFull) bool { return m.OS != nil && m.OS.BrandID == s.BrandID })
+	}
+	if s.ProductID != 0 {
+		add(func(m *MachineFull) bool {
+			p := MatchProduct(m.Config, products)
+			return p != nil && p.ID == s.ProductID
+		})
+	}
+	if s.IP != "" {
+		network, err := parseNetwork(s.IP)
+		if err != nil {
+			return nil, err
+		}
+		add(func(m *MachineFull) bool {
+			for _, ip := range m.IPs {
+				if addr := net.ParseIP(ip.Address); addr != nil && network.Contains(addr) {
+					return true
+				}
+			}
+			return false
+		})
+	}
+	if s.Ram != (Range{}) {
+		add(func(m *MachineFull) bool { return m.Config != nil && s.Ram.Contains(m.Config.RamMax) })
+	}
+	if s.Cpu != (Range{}) {
+		add(func(m *MachineFull) bool { return m.Config != nil && s.Cpu.Contains(m.Config.CpuCores) })
+	}
+
+	return func(m *MachineFull) bool {
+		if m.Machine == nil {
+			return false
+		}
+		for _, f := range filters {
+			if !f(m) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// Parse IP address or CIDR, a single address is treated as a host network
+func parseNetwork(s string) (*net.IPNet, error) {
+	if strings.Contains(s, "/") {
+		_, network, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR '%s'", s)
+		}
+		return network, nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address '%s'", s)
+	}
+	bits := 8 * net.IPv6len
+	if v4 := ip.To4(); v4 != nil {
+		ip, bits = v4, 8*net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+// Returns all machines matching the selector, products are fetched only when needed
+func (c *Client) SelectMachines(s *Selector) ([]*MachineFull, error) {
+	var products []*Product
+	if s.NeedsProducts() {
+		var err error
+		if products, err = ListAll(c.GetProducts); err != nil {
+			return nil, err
+		}
+	}
+	filter, err := s.Filter(products)
+	if err != nil {
+		return nil, err
+	}
+	machines, err := ListAll(c.GetMachinesFull)
+	if err != nil {
+		return nil, err
+	}
+	var selected []*MachineFull
+	for _, m := range machines {
+		if filter(m) {
+			selected = append(selected, m)
+		}
+	}
+	return selected, nil
+}
+
+// Returns names of all machines matching the selector
+func (c *Client) SelectNames(s *Selector) ([]string, error) {
+	machines, err := c.SelectMachines(s)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(machines))
+	for i, m := range machines {
+		names[i] = m.Name
+	}
+	return names, nil
+}
@@ -0,0 +1,103 @@
+package winvps
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelector(t *testing.T) {
+	sel, err := ParseSelector("status=running, name=VPS0*,regexp=^VPS,notes=web,template=1,brand=2,product=3,ip=10.0.0.0/8,ram=2048-,cpu=2-4")
+	require.NoError(t, err)
+	require.Equal(t, &Selector{
+		Status:     "running",
+		Name:       "VPS0*",
+		NameRegexp: "^VPS",
+		Notes:      "web",
+		TemplateID: "1",
+		BrandID:    2,
+		ProductID:  3,
+		IP:         "10.0.0.0/8",
+		Ram:        Range{Min: 2048},
+		Cpu:        Range{Min: 2, Max: 4},
+	}, sel)
+
+	sel, err = ParseSelector("cpu=2")
+	require.NoError(t, err)
+	require.Equal(t, Range{Min: 2, Max: 2}, sel.Cpu)
+
+	_, err = ParseSelector("status")
+	require.EqualError(t, err, "invalid selector 'status', expected key=value")
+	_, err = ParseSelector("color=red")
+	require.EqualError(t, err, "unknown selector key 'color'")
+	_, err = ParseSelector("ram=a-b")
+	require.EqualError(t, err, "invalid selector ram value 'a-b'")
+	_, err = ParseSelector("location=1")
+	require.Error(t, err)
+}
+
+func TestSelectorFilter(t *testing.T) {
+	products := []*Product{{ID: 1, Limits: &Limits{CpuCores: 2, RamMax: 2048}}}
+	machines := []*MachineFull{
+		{Machine: &Machine{Name: "VPS01", Status: "Running", Notes: "web-1"}, IPs: []*IP{{Version: 4, Address: "10.0.0.1"}},
+			OS: &OS{TemplateID: "1", BrandID: 1}, Config: &Limits{CpuCores: 2, RamMax: 2048}},
+		{Machine: &Machine{Name: "VPS02", Status: "Stopped", Notes: "db-1"}, IPs: []*IP{{Version: 6, Address: "2001:db8::1"}},
+			OS: &OS{TemplateID: "2", BrandID: 1}, Config: &Limits{CpuCores: 4, RamMax: 8192}},
+		{Machine: &Machine{Name: "TEST03", Status: "Running"}},
+		{},
+	}
+	selected := func(s *Selector) []string {
+		filter, err := s.Filter(products)
+		require.NoError(t, err)
+		var names []string
+		for _, m := range machines {
+			if filter(m) {
+				names = append(names, m.Name)
+			}
+		}
+		return names
+	}
+
+	require.Equal(t, []string{"VPS01", "VPS02", "TEST03"}, selected(&Selector{}))
+	require.Equal(t, []string{"VPS01", "TEST03"}, selected(&Selector{Status: "running"}))
+	require.Equal(t, []string{"VPS01", "VPS02"}, selected(&Selector{Name: "VPS*"}))
+	require.Equal(t, []string{"TEST03"}, selected(&Selector{NameRegexp: "^T.*3$"}))
+	require.Equal(t, []string{"VPS02"}, selected(&Selector{Notes: "db"}))
+	require.Equal(t, []string{"VPS02"}, selected(&Selector{TemplateID: "2"}))
+	require.Equal(t, []string{"VPS01", "VPS02"}, selected(&Selector{BrandID: 1}))
+	require.Equal(t, []string{"VPS01"}, selected(&Selector{ProductID: 1}))
+	require.Equal(t, []string{"VPS01"}, selected(&Selector{IP: "10.0.0.0/24"}))
+	require.Equal(t, []string{"VPS01"}, selected(&Selector{IP: "10.0.0.1"}))
+	require.Equal(t, []string{"VPS02"}, selected(&Selector{IP: "2001:db8::/32"}))
+	require.Equal(t, []string{"VPS02"}, selected(&Selector{Ram: Range{Min: 4096}}))
+	require.Equal(t, []string{"VPS01"}, selected(&Selector{Cpu: Range{Max: 2}}))
+	require.Empty(t, selected(&Selector{Status: "running", Cpu: Range{Min: 4}}))
+
+	_, err := (&Selector{IP: "10.0.0"}).Filter(nil)
+	require.EqualError(t, err, "invalid IP address '10.0.0'")
+	_, err = (&Selector{NameRegexp: "("}).Filter(nil)
+	require.Error(t, err)
+	_, err = (&Selector{Name: "["}).Filter(nil)
+	require.Error(t, err)
+}
+
+func TestSelectNames(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	mux.HandleFunc(apiVerPath+"machines/full", func(w http.ResponseWriter, r *http.Request) {
+		writeFixture(t, w, "machinesfull.json")
+	})
+	mux.HandleFunc(apiVerPath+"products", func(w http.ResponseWriter, r *http.Request) {
+		writeFixture(t, w, "products.json")
+	})
+
+	names, err := client.SelectNames(&Selector{IP: "127.0.0.0/8"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"VPS0123"}, names)
+
+	names, err = client.SelectNames(&Selector{ProductID: 1000})
+	require.NoError(t, err)
+	require.Empty(t, names)
+}
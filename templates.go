@@ -13,7 +13,7 @@ type Template struct {
 func (c *Client) GetTemplates(opts ...*RequestOptions) ([]*Template, *Pagination, error) {
 	u := "templates"
 
-	req, err := c.NewRequest(http.MethodGet, u, nil, opts)
+	req, err := c.newRequest("GetTemplates", http.MethodGet, u, nil, opts)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -0,0 +1,128 @@
+package winvps
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provides api token, consulted on every request so tokens can be rotated
+// without recreating the client
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// Set token source for api client, it replaces the token passed to NewClient()
+func Credentials(ts TokenSource) Option {
+	return func(c *Client) error {
+		if ts == nil {
+			return fmt.Errorf("token source is nil")
+		}
+		c.tokens = ts
+		return nil
+	}
+}
+
+// Represents a token which never changes
+type StaticToken string
+
+// Token implements TokenSource
+func (t StaticToken) Token() (string, error) {
+	return string(t), nil
+}
+
+// Returns token source reading token from env variable on every request
+func EnvToken(name string) TokenSource {
+	return envToken(name)
+}
+
+type envToken string
+
+func (e envToken) Token() (string, error) {
+	token := os.Getenv(string(e))
+	if token == "" {
+		return "", fmt.Errorf("env variable %s is empty", string(e))
+	}
+	return token, nil
+}
+
+// Returns token source reading token from file, the file is re-read when
+// its modification time or size changes. Surrounding whitespace is trimmed
+func FileToken(path string) TokenSource {
+	return &fileToken{path: path}
+}
+
+type fileToken struct {
+	path string
+
+	mu      sync.Mutex
+	token   string
+	modTime time.Time
+	size    int64
+}
+
+func (f *fileToken) Token() (string, error) {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return "", err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.token != "" && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.token, nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", f.path)
+	}
+	f.token, f.modTime, f.size = token, info.ModTime(), info.Size()
+	return token, nil
+}
+
+// Returns token source running external command and using its trimmed stdout as token.
+// The token is cached for ttl, zero ttl means the command is run on every request
+func CommandToken(ttl time.Duration, name string, args ...string) TokenSource {
+	return &commandToken{ttl: ttl, name: name, args: args}
+}
+
+type commandToken struct {
+	ttl  time.Duration
+	name string
+	args []string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func (c *commandToken) Token() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Before(c.expires) {
+		return c.token, nil
+	}
+
+	stderr := new(bytes.Buffer)
+	cmd := exec.Command(c.name, c.args...)
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("token command %s failed: %v: %s", c.name, err, strings.TrimSpace(stderr.String()))
+	}
+	token := strings.TrimSpace(string(out))
+	if token == "" {
+		return "", fmt.Errorf("token command %s returned empty token", c.name)
+	}
+	c.token, c.expires = token, time.Now().Add(c.ttl)
+	return token, nil
+}
@@ -0,0 +1,85 @@
+package winvps
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentials(t *testing.T) {
+	mux, server, _ := setup(t)
+	defer teardown(server)
+
+	mux.HandleFunc(apiVerPath+"brands", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, os.Getenv("TEST_WINVPS_TOKEN"), r.Header.Get("API-KEY"))
+		writeFixture(t, w, "brands.json")
+	})
+
+	client, err := NewClient("", BaseURL(server.URL), Credentials(EnvToken("TEST_WINVPS_TOKEN")))
+	require.NoError(t, err)
+
+	t.Setenv("TEST_WINVPS_TOKEN", "first")
+	_, _, err = client.GetBrands()
+	require.NoError(t, err)
+
+	t.Setenv("TEST_WINVPS_TOKEN", "second")
+	_, _, err = client.GetBrands()
+	require.NoError(t, err)
+
+	t.Setenv("TEST_WINVPS_TOKEN", "")
+	_, _, err = client.GetBrands()
+	require.EqualError(t, err, "unable to get api token: env variable TEST_WINVPS_TOKEN is empty")
+}
+
+func TestFileToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte("first\n"), 0600))
+
+	ts := FileToken(path)
+	got, err := ts.Token()
+	require.NoError(t, err)
+	require.Equal(t, "first", got)
+
+	require.NoError(t, os.WriteFile(path, []byte("second-token\n"), 0600))
+	got, err = ts.Token()
+	require.NoError(t, err)
+	require.Equal(t, "second-token", got)
+
+	require.NoError(t, os.Remove(path))
+	_, err = ts.Token()
+	require.Error(t, err)
+}
+
+func TestCommandToken(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "counter")
+	ts := CommandToken(time.Hour, "sh", "-c", "echo x >> "+counter+"; echo token")
+
+	for i := 0; i < 2; i++ {
+		got, err := ts.Token()
+		require.NoError(t, err)
+		require.Equal(t, "token", got)
+	}
+	data, err := os.ReadFile(counter)
+	require.NoError(t, err)
+	require.Equal(t, "x\n", string(data))
+
+	_, err = CommandToken(0, "sh", "-c", "echo denied >&2; exit 1").Token()
+	require.EqualError(t, err, "token command sh failed: exit status 1: denied")
+}
+
+func TestProfileTokenSource(t *testing.T) {
+	ts, err := (&Profile{Token: "token", TokenFile: "file"}).TokenSource()
+	require.NoError(t, err)
+	require.Equal(t, StaticToken("token"), ts)
+
+	ts, err = (&Profile{TokenFile: "file"}).TokenSource()
+	require.NoError(t, err)
+	require.Equal(t, FileToken("file"), ts)
+
+	_, err = (&Profile{Name: "empty"}).TokenSource()
+	require.EqualError(t, err, "profile 'empty' has no token")
+}
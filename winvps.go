@@ -2,9 +2,11 @@ package winvps
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"reflect"
@@ -23,8 +25,16 @@ const (
 type Client struct {
 	httpClient *http.Client
 	baseURL    *url.URL
-	token      string
+	tokens     TokenSource
 	UserAgent  string
+
+	middlewares []Middleware
+	logger      *slog.Logger
+	logBodies   bool
+	retry       *RetryPolicy
+	dryRun      *dryRun
+	audit       *audit
+	guard       *guard
 }
 
 // Represents api response
@@ -60,6 +70,26 @@ func (p *Pagination) PreviousPage() int {
 	return 0
 }
 
+// Calls paginated list method fn page by page and returns items from all pages,
+// e.g. ListAll(client.GetMachinesFull)
+func ListAll[T any](fn func(opts ...*RequestOptions) ([]T, *Pagination, error)) ([]T, error) {
+	var all []T
+	opt := &RequestOptions{Page: 1}
+	for {
+		items, page, err := fn(opt)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if page == nil {
+			return all, nil
+		}
+		if opt.Page = page.NextPage(); opt.Page == 0 {
+			return all, nil
+		}
+	}
+}
+
 // Represents pagination options
 type RequestOptions struct {
 	Limit int `url:"limit,omitempty"`
@@ -101,7 +131,7 @@ func (c *Client) parseOptions(opts ...Option) error {
 func NewClient(token string, opts ...Option) (*Client, error) {
 	c := &Client{
 		UserAgent:  userAgent,
-		token:      token,
+		tokens:     StaticToken(token),
 		httpClient: &http.Client{Timeout: time.Second * 30},
 	}
 	c.setBaseURL(baseURL)
@@ -125,17 +155,16 @@ func checkRequiredOpts(v interface{}, opts []string) error {
 	for _, opt := range opts {
 		f := reflect.Indirect(r).FieldByName(opt)
 		if !f.IsValid() {
-			return fmt.Errorf("struct %+v not contain required field %s", v, opt)
+			return fmt.Errorf("struct %T not contain required field %s", v, opt)
 		}
-		switch f.Interface().(type) {
-		case int:
-			t := f.Interface().(int)
-			if t == 0 {
+		// kinds are checked, so named types like Secret are supported
+		switch f.Kind() {
+		case reflect.Int:
+			if f.Int() == 0 {
 				return fmt.Errorf("missing required option %s", opt)
 			}
-		case string:
-			t := f.Interface().(string)
-			if t == "" {
+		case reflect.String:
+			if f.String() == "" {
 				return fmt.Errorf("missing required option %s", opt)
 			}
 		default:
@@ -146,35 +175,41 @@ func checkRequiredOpts(v interface{}, opts []string) error {
 	return nil
 }
 
-// Make an http request, check and parse response
+// Make an http request through the middleware chain, check and parse response
 func (c *Client) Do(req *http.Request, v interface{}) (*Response, error) {
-	resp, err := c.httpClient.Do(req)
+	result, _, err := c.do(req, v)
+	return result, err
+}
+
+// Same as Do() but also returns http status code, 0 if no response was received
+func (c *Client) do(req *http.Request, v interface{}) (*Response, int, error) {
+	resp, body, err := c.roundTrip(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	defer resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
 
 	if err := CheckResponse(resp); err != nil {
-		return nil, err
+		return nil, resp.StatusCode, err
 	}
 
 	// Parse data field from response
 	if v != nil {
 		result := &Response{}
-		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-			return nil, fmt.Errorf("status: %d, unable to decode response, unknown format: %v", resp.StatusCode, err)
+		if err := json.Unmarshal(body, result); err != nil {
+			return nil, resp.StatusCode, fmt.Errorf("status: %d, unable to decode response, unknown format: %v", resp.StatusCode, err)
 		}
 		// Decode the data field
 		if result.Data == nil {
-			return nil, fmt.Errorf("status: %d, missing data from response", resp.StatusCode)
+			return nil, resp.StatusCode, fmt.Errorf("status: %d, missing data from response", resp.StatusCode)
 		}
 		if err := json.Unmarshal(result.Data, v); err != nil {
-			return result, fmt.Errorf("status: %d, unable to parse response data: %s", resp.StatusCode, err)
+			return result, resp.StatusCode, fmt.Errorf("status: %d, unable to parse response data: %s", resp.StatusCode, err)
 		}
-		return result, nil
+		return result, resp.StatusCode, nil
 	}
 
-	return nil, err
+	return nil, resp.StatusCode, err
 }
 
 // Creates and validates a new request
@@ -186,7 +221,11 @@ func (c *Client) NewRequest(method, path string, opt interface{}, opts []*Reques
 	// Prepare headers
 	reqHeaders := make(http.Header)
 	reqHeaders.Set("Accept", "application/json")
-	reqHeaders.Set("API-KEY", c.token)
+	token, err := c.tokens.Token()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get api token: %v", err)
+	}
+	reqHeaders.Set("API-KEY", token)
 	reqHeaders.Set("User-Agent", c.UserAgent)
 
 	// Validate and marshall request body if any
@@ -199,7 +238,6 @@ func (c *Client) NewRequest(method, path string, opt interface{}, opts []*Reques
 					return nil, err
 				}
 			}
-			var err error
 			body, err = json.Marshal(opt)
 			if err != nil {
 				return nil, err
@@ -232,6 +270,32 @@ func (c *Client) NewRequest(method, path string, opt interface{}, opts []*Reques
 	return req, nil
 }
 
+// Creates a new request and stores the client method name in the request context
+func (c *Client) newRequest(op, method, path string, opt interface{}, opts []*RequestOptions) (*http.Request, error) {
+	req, err := c.NewRequest(method, path, opt, opts)
+	if err != nil {
+		return nil, err
+	}
+	return req.WithContext(context.WithValue(req.Context(), operationKey{}, op)), nil
+}
+
+type operationKey struct{}
+
+// Returns the client method name which created the request, e.g. "CreateMachine",
+// empty string for requests created with NewRequest directly
+func OperationFromContext(ctx context.Context) string {
+	op, _ := ctx.Value(operationKey{}).(string)
+	return op
+}
+
+type retryKey struct{}
+
+// Returns the retry attempt number stored in the request context, 0 for the first attempt
+func retryFromContext(ctx context.Context) int {
+	n, _ := ctx.Value(retryKey{}).(int)
+	return n
+}
+
 // Checks the API response for errors
 func CheckResponse(r *http.Response) error {
 	switch r.StatusCode {
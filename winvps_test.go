@@ -2,6 +2,7 @@ package winvps
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -82,3 +83,17 @@ func TestRequestHeaders(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestListAll(t *testing.T) {
+	mux, server, client := setup(t)
+	defer teardown(server)
+
+	mux.HandleFunc(apiVerPath+"machines", func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		fmt.Fprintf(w, `{"data":[{"name":"VPS%s","status":"Running"}],"pagination":{"total":2,"limit":1,"page":%s,"pages":2}}`, page, page)
+	})
+
+	got, err := ListAll(client.GetMachines)
+	require.NoError(t, err)
+	require.Equal(t, []*Machine{{Name: "VPS1", Status: "Running"}, {Name: "VPS2", Status: "Running"}}, got)
+}